@@ -0,0 +1,264 @@
+package flatstructs
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Get returns a value of the leaf with the flat key
+// from a nested structure v points to, keys are the same Keys() returns.
+// Leafs which are not reachable because of nil pointers are reported as nil,
+// leafs with omitempty option are reported even if they have zero value,
+// key which does not belong to any leaf is reported as ErrKeyNotFound.
+func (b *Builder) Get(v interface{}, key string) (interface{}, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		value interface{}
+		found bool
+	)
+	err = b.walkRoot(
+		rootNode(reflect.ValueOf(v)),
+		func(n node) error {
+			if n.key != key {
+				return nil
+			}
+			value = n.value.Interface()
+			found = true
+			return errStopWalk
+		},
+	)
+	if err != nil && err != errStopWalk {
+		return nil, err
+	}
+	if found {
+		return value, nil
+	}
+
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range p.fields {
+		if field.key != key {
+			continue
+		}
+		// Leaf could be skipped by the walk because it is not reachable,
+		// it is a nil dynamic leaf or it is empty and has omitempty option,
+		// otherwise dynamic leaf was expanded into the nested leafs.
+		leaf := field.value(reflectValue)
+		switch {
+		case !leaf.IsValid(), field.dynamic && isNilValue(leaf):
+			return nil, nil
+		case field.omitEmpty && isZeroValue(leaf):
+			if field.dynamic {
+				return nil, nil
+			}
+			return leaf.Interface(), nil
+		}
+		break
+	}
+
+	return nil, NewErrKeyNotFound(key)
+}
+
+// Set assigns the value to the leaf with the flat key
+// in a nested structure v points to, keys are the same Keys() returns.
+// Nil pointers in the path to the leaf are allocated,
+// value is converted to the leaf type like Unmarshal() does.
+// Slice and array elements could be set only if they exist,
+// values implementing Unflattener are restored with the single key,
+// values of the interfaces which are not behind the pointers
+// are not addressable and reported as ErrUnassignable,
+// key which does not belong to any leaf is reported as ErrKeyNotFound.
+func (b *Builder) Set(v interface{}, key string, value interface{}) error {
+	err := checkValue(v)
+	if err != nil {
+		return err
+	}
+
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	if isUnflattenerType(reflectValue.Type()) {
+		ok, err = b.fromMapUnflattener(map[string]interface{}{key: value}, reflectValue, "")
+	} else {
+		ok, err = b.setStruct(reflectValue, "", key, value)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return NewErrKeyNotFound(key)
+	}
+
+	return nil
+}
+
+// setStruct assigns the leaf of the struct which key
+// is prefixed with the prefix key, see Set().
+// It reports whether the leaf was found.
+func (b *Builder) setStruct(reflectValue reflect.Value, prefix string, key string, value interface{}) (bool, error) {
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		fieldKey   string
+		fieldValue reflect.Value
+	)
+	for _, field := range p.fields {
+		fieldKey = b.appendKey(prefix, field.key, field.parts)
+
+		if fieldKey == key && !isUnflattenerType(field.typ) {
+			fieldValue = field.field(reflectValue, true)
+			if !assignValue(fieldValue, value) {
+				return false, NewErrUnassignable(key, value, fieldValue.Type())
+			}
+			return true, nil
+		}
+
+		if !field.dynamic {
+			continue
+		}
+		if fieldKey != key {
+			if _, ok := b.nextKeyPart(key, fieldKey, b.keyPrefixParts(fieldKey)); !ok {
+				continue
+			}
+		}
+
+		fieldValue = field.field(reflectValue, false)
+		if fieldValue.IsValid() {
+			return b.setValue(fieldValue, fieldKey, key, value)
+		}
+
+		nested := reflect.New(field.typ).Elem()
+		ok, err := b.setValue(nested, fieldKey, key, value)
+		if err != nil || !ok {
+			return false, err
+		}
+		field.field(reflectValue, true).Set(nested)
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// setValue assigns the leaf of the value which layout
+// is known only at runtime, see Set().
+// It reports whether the leaf was found.
+func (b *Builder) setValue(reflectValue reflect.Value, prefix string, key string, value interface{}) (bool, error) {
+	if isUnflattenerType(reflectValue.Type()) {
+		return b.fromMapUnflattener(map[string]interface{}{key: value}, reflectValue, prefix)
+	}
+
+	if prefix == key {
+		if !assignValue(reflectValue, value) {
+			return false, NewErrUnassignable(key, value, reflectValue.Type())
+		}
+		return true, nil
+	}
+
+	part, ok := b.nextKeyPart(key, prefix, b.keyPrefixParts(prefix))
+	if !ok {
+		return false, nil
+	}
+
+	switch reflectValue.Kind() {
+	case reflect.Interface:
+		// Value of the interface is not addressable,
+		// so only values behind the pointers could be assigned.
+		if reflectValue.IsNil() {
+			break
+		}
+		elem := reflectValue.Elem()
+		if elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			return b.setValue(elem, prefix, key, value)
+		}
+		return false, NewErrUnassignable(key, value, elem.Type())
+	case reflect.Ptr:
+		if !reflectValue.IsNil() {
+			return b.setValue(reflectValue.Elem(), prefix, key, value)
+		}
+		nested := reflect.New(reflectValue.Type().Elem())
+		ok, err := b.setValue(nested.Elem(), prefix, key, value)
+		if err != nil || !ok {
+			return false, err
+		}
+		reflectValue.Set(nested)
+		return true, nil
+	case reflect.Struct:
+		return b.setStruct(reflectValue, prefix, key, value)
+	case reflect.Slice, reflect.Array:
+		if !b.ExpandSlices || isBytesType(reflectValue.Type()) {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || n >= reflectValue.Len() {
+			break
+		}
+		return b.setValue(reflectValue.Index(n), b.joinKey(prefix, part), key, value)
+	case reflect.Map:
+		if !b.ExpandMaps || !isExpandableMapType(reflectValue.Type()) {
+			break
+		}
+		return b.setMapEntry(reflectValue, prefix, part, key, value)
+	}
+
+	return false, nil
+}
+
+// setMapEntry assigns the leaf of the map entry
+// with the key part, see setValue().
+func (b *Builder) setMapEntry(reflectValue reflect.Value, prefix string, part string, key string, value interface{}) (bool, error) {
+	reflectType := reflectValue.Type()
+
+	mapKey, ok, err := parseMapKey(reflectType.Key(), part)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	item := reflect.New(reflectType.Elem()).Elem()
+	if current := reflectValue.MapIndex(mapKey); current.IsValid() {
+		item.Set(current)
+	}
+
+	ok, err = b.setValue(item, b.joinKey(prefix, part), key, value)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if reflectValue.IsNil() {
+		reflectValue.Set(reflect.MakeMap(reflectType))
+	}
+	reflectValue.SetMapIndex(mapKey, item)
+
+	return true, nil
+}
+
+//
+
+// Get returns a value of the leaf with the flat key.
+// It uses Default Builder.
+func Get(v interface{}, key string) (interface{}, error) {
+	return Default.Get(v, key)
+}
+
+// Set assigns the value to the leaf with the flat key.
+// It uses Default Builder.
+func Set(v interface{}, key string, value interface{}) error {
+	return Default.Set(v, key, value)
+}
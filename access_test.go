@@ -0,0 +1,201 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderGet(t *testing.T) {
+	type Item struct {
+		Name string
+	}
+	type Flat struct {
+		Baz string
+	}
+	type Nested struct {
+		Foo    int
+		Bar    *Flat
+		Items  []Item
+		Labels map[string]string
+		Meta   interface{}
+	}
+	sample := Nested{
+		Foo:    1,
+		Items:  []Item{{"foo"}},
+		Labels: map[string]string{"app": "web"},
+		Meta:   Flat{"baz"},
+	}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+	builder.ExpandMaps = true
+
+	for _, test := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"Foo", 1},
+		{"Bar.Baz", nil},
+		{"Items.0.Name", "foo"},
+		{"Labels.app", "web"},
+		{"Meta.Baz", "baz"},
+	} {
+		value, err := builder.Get(&sample, test.key)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.Equal(t, test.value, value, spew.Sdump(test))
+	}
+
+	for _, key := range []string{"Items.1.Name", "Items", "Labels", "Meta"} {
+		_, err := builder.Get(&sample, key)
+		assert.Equal(t, &ErrKeyNotFound{key}, err, spew.Sdump(sample))
+	}
+}
+
+func TestBuilderGetOmitEmpty(t *testing.T) {
+	type Flat struct {
+		Name string `key:",omitempty"`
+	}
+	sample := Flat{}
+
+	value, err := NewBuilder("key", ".").Get(&sample, "Name")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(t, "", value, spew.Sdump(sample))
+}
+
+func TestBuilderSet(t *testing.T) {
+	type Item struct {
+		Name string
+	}
+	type Flat struct {
+		Baz string
+	}
+	type Nested struct {
+		Foo    int
+		Bar    *Flat
+		Items  []Item
+		Labels map[string]*Flat
+	}
+	sample := Nested{Items: []Item{{"foo"}}}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+	builder.ExpandMaps = true
+
+	for _, test := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"Foo", float64(1)},
+		{"Bar.Baz", "baz"},
+		{"Items.0.Name", "bar"},
+		{"Labels.app.Baz", "web"},
+	} {
+		err := builder.Set(&sample, test.key, test.value)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	assert.Equal(
+		t,
+		Nested{
+			Foo:    1,
+			Bar:    &Flat{"baz"},
+			Items:  []Item{{"bar"}},
+			Labels: map[string]*Flat{"app": {"web"}},
+		},
+		sample,
+		spew.Sdump(sample),
+	)
+
+	for _, key := range []string{"Items.1.Name", "Qux", "Bar.Qux"} {
+		err := builder.Set(&sample, key, "qux")
+		assert.Equal(t, &ErrKeyNotFound{key}, err, spew.Sdump(sample))
+	}
+
+	err := builder.Set(&sample, "Foo", "foo")
+	if _, ok := err.(*ErrUnassignable); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrUnassignable, got '%T'",
+			err,
+		)
+	}
+}
+
+func TestBuilderSetUnflattener(t *testing.T) {
+	type Order struct {
+		ID    int
+		Price flattenerTestMoney
+		Tax   *flattenerTestMoney
+	}
+	sample := Order{ID: 1, Price: flattenerTestMoney{1050, "USD"}}
+
+	builder := NewBuilder("key", ".")
+
+	for _, test := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"Price.amount", "12.00"},
+		{"Tax.currency", "EUR"},
+	} {
+		err := builder.Set(&sample, test.key, test.value)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	assert.Equal(
+		t,
+		Order{
+			ID:    1,
+			Price: flattenerTestMoney{1200, "USD"},
+			Tax:   &flattenerTestMoney{0, "EUR"},
+		},
+		sample,
+		spew.Sdump(sample),
+	)
+
+	err := builder.Set(&sample, "Price.qux", "qux")
+	assert.Equal(t, &ErrKeyNotFound{"Price.qux"}, err, spew.Sdump(sample))
+}
+
+func TestBuilderSetInterface(t *testing.T) {
+	type Flat struct {
+		Baz string
+	}
+	type Nested struct {
+		Meta  interface{}
+		Extra interface{}
+	}
+	sample := Nested{Meta: Flat{"baz"}, Extra: &Flat{"baz"}}
+
+	builder := NewBuilder("key", ".")
+
+	err := builder.Set(&sample, "Extra.Baz", "qux")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, &Flat{"qux"}, sample.Extra, spew.Sdump(sample))
+
+	err = builder.Set(&sample, "Meta.Baz", "qux")
+	if _, ok := err.(*ErrUnassignable); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrUnassignable, got '%T'",
+			err,
+		)
+	}
+	assert.Equal(t, Flat{"baz"}, sample.Meta, spew.Sdump(sample))
+}
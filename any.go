@@ -0,0 +1,132 @@
+package flatstructs
+
+import (
+	"reflect"
+)
+
+// FlattenAny creates a flat slice of key/value pairs from the value
+// of any type. It is intended for the documents decoded into
+// the interface{}(for example with encoding/json), so it descends
+// into generic maps and slices regardless of the Builder.ExpandMaps
+// and Builder.ExpandSlices, nil values(JSON null) are kept as nil leafs.
+// Structs found in the document are flattened the same way Pairs() does.
+// Scalar value is represented as a single pair with an empty key.
+func (b *Builder) FlattenAny(v interface{}) ([]Pair, error) {
+	pairs := []Pair{}
+	err := b.walkAny(
+		node{value: reflect.ValueOf(v)},
+		func(n node) error {
+			if !n.value.IsValid() {
+				pairs = append(pairs, Pair{n.key, nil})
+				return nil
+			}
+			pairs = append(pairs, Pair{n.key, n.value.Interface()})
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+// walkAny walks the n.value of any type, see FlattenAny().
+// Unlike walk() fn could receive invalid value for nil interfaces.
+func (b *Builder) walkAny(n node, fn func(node) error) error {
+	if f, ok := flattener(n.value); ok {
+		return b.walkFlattener(n, f, fn)
+	}
+
+	switch n.value.Kind() {
+	case reflect.Interface:
+		if n.value.IsNil() {
+			n.value = reflect.Value{}
+			return fn(n)
+		}
+		n.value = n.value.Elem()
+		return b.walkAny(n, fn)
+	case reflect.Ptr:
+		if n.value.IsNil() {
+			return nil
+		}
+		err := n.enter()
+		if err != nil {
+			return err
+		}
+		defer n.leave()
+
+		n.value = n.value.Elem()
+		return b.walkAny(n, fn)
+	case reflect.Struct:
+		p, err := b.plan(n.value.Type())
+		if err != nil {
+			return err
+		}
+		if len(p.fields) > 0 {
+			return b.walk(n, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		if !isBytesType(n.value.Type()) {
+			if n.value.Kind() == reflect.Slice && n.value.Len() > 0 {
+				err := n.enter()
+				if err != nil {
+					return err
+				}
+				defer n.leave()
+			}
+
+			pushed := n.push()
+			defer n.pop(pushed)
+
+			for k := 0; k < n.value.Len(); k++ {
+				child, err := b.indexNode(n, k)
+				if err != nil {
+					return err
+				}
+				err = b.walkAny(child, fn)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if isExpandableMapType(n.value.Type()) {
+			err := n.enter()
+			if err != nil {
+				return err
+			}
+			defer n.leave()
+
+			entries, err := sortedMapEntries(n.value)
+			if err != nil {
+				return err
+			}
+			pushed := n.push()
+			defer n.pop(pushed)
+
+			for _, entry := range entries {
+				child, err := b.mapEntryNode(n, entry)
+				if err != nil {
+					return err
+				}
+				err = b.walkAny(child, fn)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	return fn(n)
+}
+
+//
+
+// FlattenAny creates a flat slice of key/value pairs from the value of any type.
+// It uses Default Builder.
+func FlattenAny(v interface{}) ([]Pair, error) {
+	return Default.FlattenAny(v)
+}
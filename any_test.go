@@ -0,0 +1,112 @@
+package flatstructs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderFlattenAnyJSON(t *testing.T) {
+	var (
+		sample interface{}
+	)
+
+	err := json.Unmarshal(
+		[]byte(`{
+			"id": 1,
+			"source": null,
+			"tags": ["a", "b"],
+			"scope": {
+				"request": {"headers": {"userAgent": "curl"}},
+				"hosts": [{"host": "127.0.0.1", "port": 1337}]
+			}
+		}`),
+		&sample,
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	pairs, err := NewBuilder("key", ".").FlattenAny(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"id", float64(1)},
+			{"scope.hosts.0.host", "127.0.0.1"},
+			{"scope.hosts.0.port", float64(1337)},
+			{"scope.request.headers.userAgent", "curl"},
+			{"source", nil},
+			{"tags.0", "a"},
+			{"tags.1", "b"},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderFlattenAnyStruct(t *testing.T) {
+	type Flat struct {
+		Baz string
+	}
+	type Nested struct {
+		Foo string
+		Bar *Flat
+	}
+	sample := []interface{}{
+		&Nested{"foo", &Flat{"baz"}},
+		map[string]interface{}{"Foo": "foo"},
+	}
+
+	pairs, err := NewBuilder("key", ".").FlattenAny(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"0.Foo", "foo"},
+			{"0.Bar.Baz", "baz"},
+			{"1.Foo", "foo"},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderFlattenAnyScalar(t *testing.T) {
+	pairs, err := FlattenAny("foo")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{{"", "foo"}},
+		pairs,
+	)
+}
+
+func TestBuilderFlattenAnyNil(t *testing.T) {
+	pairs, err := FlattenAny(nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{{"", nil}},
+		pairs,
+	)
+}
@@ -0,0 +1,532 @@
+package flatstructs
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// EnvListDelimiter delimits the elements of the slices
+// in the environment variables.
+const EnvListDelimiter = ","
+
+// envListEscape escapes delimiters, spaces around the elements
+// and itself in the elements of the slices, see splitList().
+const envListEscape = '\\'
+
+// LoadEnv assigns values of the environment variables
+// to the matching fields of a nested structure v points to.
+// Variable names are derived from the key parts which are
+// upper cased and joined with _ and prefixed with the prefix(APP_SCOPE_HOST),
+// characters which are not letters or digits are replaced with _.
+// Variables are looked up with the Builder.LookupEnv.
+// Strings are parsed into the field types, types implementing
+// encoding.TextUnmarshaler or sql.Scanner parse them on their own,
+// types implementing Unflattener look up the variables of their leafs
+// which names are suffixed with the leaf key parts(see Environ()),
+// slice elements are delimited with the EnvListDelimiter,
+// spaces around the elements are trimmed, backslash escapes
+// the delimiter, the spaces and itself.
+// Nil pointers to the nested structures are allocated only
+// if there is a variable for at least one of their leafs.
+func (b *Builder) LoadEnv(v interface{}, prefix string) error {
+	err := checkValue(v)
+	if err != nil {
+		return err
+	}
+
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return err
+	}
+
+	lookup := b.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	if isUnflattenerType(reflectValue.Type()) {
+		_, err = unflattenEnv(reflectValue, prefix, nil, lookup)
+		return err
+	}
+
+	var (
+		fieldValue reflect.Value
+		name       string
+		value      string
+		ok         bool
+	)
+	for _, field := range p.fields {
+		if isUnflattenerType(field.typ) {
+			fieldValue = field.field(reflectValue, false)
+			if fieldValue.IsValid() {
+				_, err = unflattenEnv(fieldValue, prefix, field.parts, lookup)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			nested := reflect.New(field.typ).Elem()
+			ok, err = unflattenEnv(nested, prefix, field.parts, lookup)
+			if err != nil {
+				return err
+			}
+			if ok {
+				field.field(reflectValue, true).Set(nested)
+			}
+			continue
+		}
+
+		name = envName(prefix, field.parts)
+		value, ok = lookup(name)
+		if !ok {
+			continue
+		}
+
+		fieldValue = field.field(reflectValue, true)
+		ok, err = parseValue(fieldValue, value)
+		if err != nil || !ok {
+			return NewErrInvalidEnv(name, value, fieldValue.Type(), err)
+		}
+	}
+
+	return nil
+}
+
+// unflattenEnv assigns the value implementing Unflattener
+// using the variables of the leafs nested into the key parts,
+// see LoadEnv().
+func unflattenEnv(reflectValue reflect.Value, prefix string, parts []string, lookup func(string) (string, bool)) (bool, error) {
+	return unflatten(reflectValue, func(key []string) (interface{}, bool) {
+		value, ok := lookup(envName(prefix, append(append([]string{}, parts...), key...)))
+		return value, ok
+	})
+}
+
+// envName returns a name of the environment variable
+// for the key parts, see LoadEnv().
+func envName(prefix string, parts []string) string {
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	name = strings.Map(
+		func(r rune) rune {
+			if r == '_' || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		},
+		name,
+	)
+
+	prefix = strings.TrimSuffix(prefix, "_")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// parseValue parses the string into the dst,
+// allocating pointers if required.
+// It reports whether the type of the dst is supported.
+func parseValue(dst reflect.Value, s string) (bool, error) {
+	if dst.Kind() == reflect.Ptr {
+		if !dst.IsNil() {
+			return parseValue(dst.Elem(), s)
+		}
+		ptr := reflect.New(dst.Type().Elem())
+		ok, err := parseValue(ptr.Elem(), s)
+		if err != nil || !ok {
+			return ok, err
+		}
+		dst.Set(ptr)
+		return true, nil
+	}
+
+	if dst.CanAddr() {
+		switch ptr := dst.Addr().Interface().(type) {
+		case encoding.TextUnmarshaler:
+			return true, ptr.UnmarshalText([]byte(s))
+		case sql.Scanner:
+			return true, ptr.Scan(s)
+		}
+	}
+
+	reflectType := dst.Type()
+	switch reflectType.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return true, err
+		}
+		dst.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if reflectType == durationType {
+			v, err := time.ParseDuration(s)
+			if err != nil {
+				return true, err
+			}
+			dst.SetInt(int64(v))
+			break
+		}
+		v, err := strconv.ParseInt(s, 0, reflectType.Bits())
+		if err != nil {
+			return true, err
+		}
+		dst.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v, err := strconv.ParseUint(s, 0, reflectType.Bits())
+		if err != nil {
+			return true, err
+		}
+		dst.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, reflectType.Bits())
+		if err != nil {
+			return true, err
+		}
+		dst.SetFloat(v)
+	case reflect.Slice:
+		if isBytesType(reflectType) {
+			dst.SetBytes([]byte(s))
+			break
+		}
+		return parseSlice(dst, s)
+	case reflect.Interface:
+		if reflectType.NumMethod() > 0 {
+			return false, nil
+		}
+		dst.Set(reflect.ValueOf(s))
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// parseSlice parses the elements delimited with
+// the EnvListDelimiter into the dst, see parseValue().
+func parseSlice(dst reflect.Value, s string) (bool, error) {
+	var (
+		parts = []string{}
+		items reflect.Value
+	)
+	if s != "" {
+		parts = splitList(s)
+	}
+
+	items = reflect.MakeSlice(dst.Type(), len(parts), len(parts))
+	for k, part := range parts {
+		ok, err := parseValue(items.Index(k), part)
+		if err != nil || !ok {
+			return ok, err
+		}
+	}
+	dst.Set(items)
+
+	return true, nil
+}
+
+// splitList splits s with the EnvListDelimiter trimming
+// spaces around the elements, characters preceded by
+// the envListEscape are taken as is, see escapeListItem().
+func splitList(s string) []string {
+	var (
+		parts = []string{}
+		part  = []byte{}
+		// end is a length of the part without trailing spaces.
+		end int
+	)
+
+	for k := 0; k < len(s); {
+		r, size := utf8.DecodeRuneInString(s[k:])
+		switch {
+		case r == envListEscape && k+size < len(s):
+			k += size
+			_, size = utf8.DecodeRuneInString(s[k:])
+			part = append(part, s[k:k+size]...)
+			end = len(part)
+		case strings.HasPrefix(s[k:], EnvListDelimiter):
+			parts = append(parts, string(part[:end]))
+			part = part[:0]
+			end = 0
+			size = len(EnvListDelimiter)
+		case unicode.IsSpace(r):
+			if len(part) > 0 {
+				part = append(part, s[k:k+size]...)
+			}
+		default:
+			part = append(part, s[k:k+size]...)
+			end = len(part)
+		}
+		k += size
+	}
+
+	return append(parts, string(part[:end]))
+}
+
+// Environ creates a slice of NAME=value environment variables
+// from a nested structure v points to, it is suitable for exec.Cmd.Env.
+// Variable names are the same LoadEnv() looks up, values
+// are formatted so LoadEnv() could parse them back.
+// Values implementing Flattener are represented with the variables
+// of their leafs which names are suffixed with the leaf key parts.
+// Leafs which are not reachable or nil are skipped,
+// as well as leafs which could not be represented as a string
+// like functions, channels and maps.
+func (b *Builder) Environ(v interface{}, prefix string) ([]string, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := flattener(reflectValue); ok {
+		return appendEnv([]string{}, prefix, nil, reflectValue)
+	}
+
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		env        = make([]string, 0, len(p.fields))
+		fieldValue reflect.Value
+	)
+	for _, field := range p.fields {
+		fieldValue = field.value(reflectValue)
+		if !fieldValue.IsValid() {
+			continue
+		}
+
+		env, err = appendEnv(env, prefix, field.parts, fieldValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
+// appendEnv appends the variable for the value with the key parts
+// to the env, values implementing Flattener are represented
+// with the variables of their leafs, see Environ().
+func appendEnv(env []string, prefix string, parts []string, reflectValue reflect.Value) ([]string, error) {
+	for reflectValue.Kind() == reflect.Ptr || reflectValue.Kind() == reflect.Interface {
+		if reflectValue.IsNil() {
+			return env, nil
+		}
+		reflectValue = reflectValue.Elem()
+	}
+
+	f, ok := flattener(reflectValue)
+	if !ok {
+		value, ok, err := formatValue(reflectValue)
+		if err != nil || !ok {
+			return env, err
+		}
+		return append(env, envName(prefix, parts)+"="+value), nil
+	}
+
+	var (
+		names  = []string{}
+		values = []interface{}{}
+	)
+	f.FlattenInto(func(key []string, v interface{}) {
+		names = append(names, envName(prefix, append(append([]string{}, parts...), key...)))
+		values = append(values, v)
+	})
+
+	for k, v := range values {
+		if v == nil {
+			continue
+		}
+		value, ok, err := formatValue(reflect.ValueOf(v))
+		if err != nil {
+			return env, err
+		}
+		if ok {
+			env = append(env, names[k]+"="+value)
+		}
+	}
+
+	return env, nil
+}
+
+// WriteDotenv writes the environment variables(see Environ())
+// from a nested structure v points to into the w in .env format,
+// values are double quoted, quotes, backslashes,
+// dollars and line breaks are escaped with a backslash.
+func (b *Builder) WriteDotenv(w io.Writer, v interface{}, prefix string) error {
+	env, err := b.Environ(v, prefix)
+	if err != nil {
+		return err
+	}
+
+	for _, variable := range env {
+		parts := strings.SplitN(variable, "=", 2)
+		_, err = io.WriteString(w, parts[0]+"="+dotenvQuote(parts[1])+"\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	dotenvEscaper = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+)
+
+func dotenvQuote(value string) string {
+	return `"` + dotenvEscaper.Replace(value) + `"`
+}
+
+// formatValue formats the value as a string which
+// could be parsed back with parseValue().
+// It reports whether the value could be represented as a string.
+func formatValue(reflectValue reflect.Value) (string, bool, error) {
+	switch reflectValue.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if reflectValue.IsNil() {
+			return "", false, nil
+		}
+		return formatValue(reflectValue.Elem())
+	}
+
+	if reflectValue.CanInterface() {
+		switch v := reflectValue.Interface().(type) {
+		case encoding.TextMarshaler:
+			text, err := v.MarshalText()
+			return string(text), err == nil, err
+		case driver.Valuer:
+			value, err := v.Value()
+			if err != nil || value == nil {
+				return "", false, err
+			}
+			return formatValue(reflect.ValueOf(value))
+		case time.Duration:
+			return v.String(), true, nil
+		}
+	}
+	if reflectValue.CanAddr() {
+		if v, ok := reflectValue.Addr().Interface().(encoding.TextMarshaler); ok {
+			text, err := v.MarshalText()
+			return string(text), err == nil, err
+		}
+	}
+
+	reflectType := reflectValue.Type()
+	switch reflectType.Kind() {
+	case reflect.String:
+		return reflectValue.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(reflectValue.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(reflectValue.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(reflectValue.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(reflectValue.Float(), 'g', -1, reflectType.Bits()), true, nil
+	case reflect.Slice, reflect.Array:
+		if isBytesType(reflectType) {
+			if reflectType.Kind() == reflect.Slice {
+				return string(reflectValue.Bytes()), true, nil
+			}
+			break
+		}
+		return formatSlice(reflectValue)
+	}
+
+	return "", false, nil
+}
+
+// formatSlice formats the elements delimited with
+// the EnvListDelimiter, see formatValue() and escapeListItem().
+func formatSlice(reflectValue reflect.Value) (string, bool, error) {
+	items := make([]string, reflectValue.Len())
+	for k := range items {
+		item, ok, err := formatValue(reflectValue.Index(k))
+		if err != nil || !ok {
+			return "", false, err
+		}
+		items[k] = escapeListItem(item)
+	}
+
+	return strings.Join(items, EnvListDelimiter), true, nil
+}
+
+// escapeListItem escapes the delimiters, the spaces around
+// the item and the envListEscape, so splitList() returns
+// the item as is.
+func escapeListItem(item string) string {
+	var (
+		escaped = make([]byte, 0, len(item))
+		start   = len(item) - len(strings.TrimLeftFunc(item, unicode.IsSpace))
+		end     = len(strings.TrimRightFunc(item, unicode.IsSpace))
+	)
+
+	for k := 0; k < len(item); {
+		r, size := utf8.DecodeRuneInString(item[k:])
+		if k < start || k >= end || r == envListEscape ||
+			strings.HasPrefix(item[k:], EnvListDelimiter) {
+			escaped = append(escaped, envListEscape)
+		}
+		escaped = append(escaped, item[k:k+size]...)
+		k += size
+	}
+
+	return string(escaped)
+}
+
+//
+
+// LoadEnv assigns values of the environment variables
+// to the matching fields of a nested structure v points to.
+// It uses Default Builder.
+func LoadEnv(v interface{}, prefix string) error {
+	return Default.LoadEnv(v, prefix)
+}
+
+// Environ creates a slice of NAME=value environment variables
+// from a nested structure v points to.
+// It uses Default Builder.
+func Environ(v interface{}, prefix string) ([]string, error) {
+	return Default.Environ(v, prefix)
+}
+
+// WriteDotenv writes the environment variables from
+// a nested structure v points to into the w in .env format.
+// It uses Default Builder.
+func WriteDotenv(w io.Writer, v interface{}, prefix string) error {
+	return Default.WriteDotenv(w, v, prefix)
+}
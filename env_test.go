@@ -0,0 +1,317 @@
+package flatstructs
+
+import (
+	"bytes"
+	"database/sql"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func envTestLookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestBuilderLoadEnv(t *testing.T) {
+	type Database struct {
+		Host     string
+		Port     uint16
+		Timeout  time.Duration
+		Password *string
+		Name     sql.NullString
+	}
+	type Config struct {
+		Debug     bool
+		Ratio     float64
+		IP        net.IP
+		Hosts     []string
+		Ports     []int
+		CreatedAt time.Time
+		Database  *Database `key:"db"`
+		Cache     *Database
+	}
+	var (
+		sample   = Config{}
+		password = "secret"
+	)
+
+	builder := NewBuilder("key", ".")
+	builder.NameMapper = SnakeCase
+	builder.LookupEnv = envTestLookup(map[string]string{
+		"APP_DEBUG":       "true",
+		"APP_RATIO":       "0.5",
+		"APP_IP":          "127.0.0.1",
+		"APP_HOSTS":       "foo, bar",
+		"APP_PORTS":       "80,443",
+		"APP_CREATED_AT":  "2006-01-02T15:04:05Z",
+		"APP_DB_HOST":     "localhost",
+		"APP_DB_PORT":     "5432",
+		"APP_DB_TIMEOUT":  "5s",
+		"APP_DB_PASSWORD": "secret",
+		"APP_DB_NAME":     "app",
+	})
+
+	err := builder.LoadEnv(&sample, "APP_")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		Config{
+			Debug:     true,
+			Ratio:     0.5,
+			IP:        net.ParseIP("127.0.0.1"),
+			Hosts:     []string{"foo", "bar"},
+			Ports:     []int{80, 443},
+			CreatedAt: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+			Database: &Database{
+				Host:     "localhost",
+				Port:     5432,
+				Timeout:  5 * time.Second,
+				Password: &password,
+				Name:     sql.NullString{String: "app", Valid: true},
+			},
+		},
+		sample,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderLoadEnvInvalid(t *testing.T) {
+	type Config struct {
+		Port    uint16
+		Handler func()
+	}
+
+	for _, test := range []struct {
+		env  map[string]string
+		name string
+	}{
+		{map[string]string{"PORT": "65536"}, "PORT"},
+		{map[string]string{"HANDLER": "foo"}, "HANDLER"},
+	} {
+		builder := NewBuilder("key", ".")
+		builder.LookupEnv = envTestLookup(test.env)
+
+		sample := Config{}
+		err := builder.LoadEnv(&sample, "")
+		if e, ok := err.(*ErrInvalidEnv); !ok || e.name != test.name {
+			t.Errorf(
+				"Invalid error, expected ErrInvalidEnv for '%s', got '%#v'",
+				test.name,
+				err,
+			)
+		}
+	}
+}
+
+func TestBuilderEnvironRoundTrip(t *testing.T) {
+	type Database struct {
+		Host     string
+		Timeout  time.Duration
+		Password *string
+		Name     sql.NullString
+	}
+	type Config struct {
+		Debug    bool
+		Ratio    float32
+		IP       net.IP
+		Ports    []int
+		Database *Database `key:"db"`
+		Cache    *Database
+		Handler  func()
+	}
+	sample := Config{
+		Debug: true,
+		Ratio: 0.1,
+		IP:    net.ParseIP("127.0.0.1"),
+		Ports: []int{80, 443},
+		Database: &Database{
+			Host:    "localhost",
+			Timeout: time.Minute,
+		},
+	}
+
+	builder := NewBuilder("key", ".")
+	builder.NameMapper = SnakeCase
+
+	env, err := builder.Environ(&sample, "APP")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			"APP_DEBUG=true",
+			"APP_RATIO=0.1",
+			"APP_IP=127.0.0.1",
+			"APP_PORTS=80,443",
+			"APP_DB_HOST=localhost",
+			"APP_DB_TIMEOUT=1m0s",
+		},
+		env,
+		spew.Sdump(sample),
+	)
+
+	mapping := map[string]string{}
+	for _, variable := range env {
+		parts := strings.SplitN(variable, "=", 2)
+		mapping[parts[0]] = parts[1]
+	}
+	builder.LookupEnv = envTestLookup(mapping)
+
+	result := Config{}
+	err = builder.LoadEnv(&result, "APP")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(env),
+	)
+}
+
+func TestBuilderEnvironEscape(t *testing.T) {
+	type Config struct {
+		Tags []string
+	}
+	sample := Config{Tags: []string{"a,b", " c", `d\`, "e "}}
+
+	env, err := Environ(&sample, "APP")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{`APP_TAGS=a\,b,\ c,d\\,e\ `},
+		env,
+		spew.Sdump(sample),
+	)
+
+	builder := NewBuilder("key", ".")
+	builder.LookupEnv = envTestLookup(map[string]string{
+		"APP_TAGS": strings.TrimPrefix(env[0], "APP_TAGS="),
+	})
+
+	result := Config{}
+	err = builder.LoadEnv(&result, "APP")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(env),
+	)
+}
+
+func TestBuilderEnvironFlattener(t *testing.T) {
+	type Order struct {
+		ID    int
+		Price flattenerTestMoney
+		Tax   *flattenerTestMoney
+		Total *flattenerTestMoney
+	}
+	sample := Order{
+		ID:    1,
+		Price: flattenerTestMoney{1050, "USD"},
+		Tax:   &flattenerTestMoney{105, "USD"},
+	}
+
+	env, err := NewBuilder("key", ".").Environ(&sample, "APP")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			"APP_ID=1",
+			"APP_PRICE_AMOUNT=10.50",
+			"APP_PRICE_CURRENCY=USD",
+			"APP_TAX_AMOUNT=1.05",
+			"APP_TAX_CURRENCY=USD",
+		},
+		env,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderLoadEnvUnflattener(t *testing.T) {
+	type Order struct {
+		ID    int
+		Price flattenerTestMoney
+		Tax   *flattenerTestMoney
+		Total *flattenerTestMoney
+	}
+	sample := Order{}
+
+	builder := NewBuilder("key", ".")
+	builder.LookupEnv = envTestLookup(map[string]string{
+		"APP_ID":             "1",
+		"APP_PRICE_AMOUNT":   "10.50",
+		"APP_PRICE_CURRENCY": "USD",
+		"APP_TAX_CURRENCY":   "EUR",
+	})
+
+	err := builder.LoadEnv(&sample, "APP")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		Order{
+			ID:    1,
+			Price: flattenerTestMoney{1050, "USD"},
+			Tax:   &flattenerTestMoney{0, "EUR"},
+		},
+		sample,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderWriteDotenv(t *testing.T) {
+	type Config struct {
+		Name     string
+		Password string
+	}
+	sample := Config{"foo", "$ecret \"quoted\"\nline \\"}
+
+	buf := bytes.NewBuffer(nil)
+	err := NewBuilder("key", ".").WriteDotenv(buf, &sample, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		"NAME=\"foo\"\n"+
+			`PASSWORD="\$ecret \"quoted\"\nline \\"`+"\n",
+		buf.String(),
+		spew.Sdump(sample),
+	)
+}
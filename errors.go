@@ -3,6 +3,7 @@ package flatstructs
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 type ErrInvalid struct {
@@ -55,3 +56,220 @@ func (e *ErrPtrRequired) Error() string {
 func NewErrPtrRequired(v interface{}) error {
 	return &ErrPtrRequired{v}
 }
+
+//
+
+type ErrUnassignable struct {
+	key      string
+	v        interface{}
+	expected reflect.Type
+}
+
+func (e *ErrUnassignable) Error() string {
+	return fmt.Sprintf(
+		"Value '%#v' for key '%s' could not be assigned to '%s'",
+		e.v,
+		e.key,
+		e.expected,
+	)
+}
+
+func NewErrUnassignable(key string, v interface{}, expected reflect.Type) error {
+	return &ErrUnassignable{key, v, expected}
+}
+
+//
+
+type ErrInvalidType struct {
+	expected reflect.Type
+	got      reflect.Type
+}
+
+func (e *ErrInvalidType) Error() string {
+	return fmt.Sprintf(
+		"Expected '%s' type, got '%s'",
+		e.expected,
+		e.got,
+	)
+}
+
+func NewErrInvalidType(expected, got reflect.Type) error {
+	return &ErrInvalidType{expected, got}
+}
+
+//
+
+type ErrKeyConflict struct {
+	leaf   string
+	branch string
+}
+
+func (e *ErrKeyConflict) Error() string {
+	return fmt.Sprintf(
+		"Key '%s' is used both as a leaf and as a branch of '%s'",
+		e.leaf,
+		e.branch,
+	)
+}
+
+func NewErrKeyConflict(leaf, branch string) error {
+	return &ErrKeyConflict{leaf, branch}
+}
+
+//
+
+type ErrKeyCollision struct {
+	key   string
+	field string
+	other string
+}
+
+func (e *ErrKeyCollision) Error() string {
+	return fmt.Sprintf(
+		"Key '%s' of the field '%s' collides with the key of the field '%s'",
+		e.key,
+		e.other,
+		e.field,
+	)
+}
+
+func NewErrKeyCollision(key, field, other string) error {
+	return &ErrKeyCollision{key, field, other}
+}
+
+//
+
+type ErrCycle struct {
+	field string
+}
+
+func (e *ErrCycle) Error() string {
+	return fmt.Sprintf(
+		"Cycle detected, field '%s' points to one of its parents",
+		e.field,
+	)
+}
+
+func NewErrCycle(field string) error {
+	return &ErrCycle{field}
+}
+
+//
+
+type ErrMaxDepth struct {
+	field string
+	max   int
+}
+
+func (e *ErrMaxDepth) Error() string {
+	return fmt.Sprintf(
+		"Field '%s' is nested deeper than %d key parts",
+		e.field,
+		e.max,
+	)
+}
+
+func NewErrMaxDepth(field string, max int) error {
+	return &ErrMaxDepth{field, max}
+}
+
+//
+
+type ErrInvalidKey struct {
+	key    string
+	offset int
+}
+
+func (e *ErrInvalidKey) Error() string {
+	return fmt.Sprintf(
+		"Key '%s' is malformed at offset %d",
+		e.key,
+		e.offset,
+	)
+}
+
+func NewErrInvalidKey(key string, offset int) error {
+	return &ErrInvalidKey{key, offset}
+}
+
+//
+
+type ErrKeyNotFound struct {
+	key string
+}
+
+func (e *ErrKeyNotFound) Error() string {
+	return fmt.Sprintf(
+		"Key '%s' does not belong to any field",
+		e.key,
+	)
+}
+
+func NewErrKeyNotFound(key string) error {
+	return &ErrKeyNotFound{key}
+}
+
+//
+
+type ErrUnmappedColumns struct {
+	columns []string
+}
+
+func (e *ErrUnmappedColumns) Error() string {
+	return fmt.Sprintf(
+		"Columns '%s' do not match any field",
+		strings.Join(e.columns, "', '"),
+	)
+}
+
+func NewErrUnmappedColumns(columns []string) error {
+	return &ErrUnmappedColumns{columns}
+}
+
+//
+
+type ErrUnmappedFields struct {
+	keys []string
+}
+
+func (e *ErrUnmappedFields) Error() string {
+	return fmt.Sprintf(
+		"Fields with keys '%s' do not match any column",
+		strings.Join(e.keys, "', '"),
+	)
+}
+
+func NewErrUnmappedFields(keys []string) error {
+	return &ErrUnmappedFields{keys}
+}
+
+//
+
+type ErrInvalidEnv struct {
+	name     string
+	value    string
+	expected reflect.Type
+	err      error
+}
+
+func (e *ErrInvalidEnv) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf(
+			"Environment variable '%s' value '%s' could not be parsed into the unsupported type '%s'",
+			e.name,
+			e.value,
+			e.expected,
+		)
+	}
+	return fmt.Sprintf(
+		"Environment variable '%s' value '%s' could not be parsed into the type '%s': %s",
+		e.name,
+		e.value,
+		e.expected,
+		e.err,
+	)
+}
+
+func NewErrInvalidEnv(name string, value string, expected reflect.Type, err error) error {
+	return &ErrInvalidEnv{name, value, expected, err}
+}
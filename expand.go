@@ -0,0 +1,138 @@
+package flatstructs
+
+import (
+	"sort"
+	"strconv"
+)
+
+// expandNode is a node of the tree which is built by Expand().
+type expandNode struct {
+	// key is a full flat key of the node.
+	key      string
+	value    interface{}
+	leaf     bool
+	children map[string]*expandNode
+	order    []string
+}
+
+// Expand creates a nested map from a flat map splitting
+// keys with Builder.KeySyntax or Builder.KeyDelimiter, this is the reverse
+// of the FlattenAny() for the documents.
+// Nested nodes which have only sequential numeric key parts
+// starting from zero are represented as a []interface{}.
+// Key which is used both as a leaf and as a branch(a and a.b)
+// is reported as ErrKeyConflict, key which has no parts(root key
+// of the JSONPointer or Brackets syntax) is reported as ErrInvalidKey.
+// Keys are not splitted if there is no Builder.KeySyntax
+// and Builder.KeyDelimiter is empty.
+func (b *Builder) Expand(m map[string]interface{}) (map[string]interface{}, error) {
+	var (
+		root  = newExpandNode("")
+		keys  = make([]string, 0, len(m))
+		parts []string
+		err   error
+	)
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		parts, err = b.splitKey(k)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == 0 {
+			return nil, NewErrInvalidKey(k, 0)
+		}
+		err = root.insert(parts, 0, k, m[k], b.joinKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return root.mapping(), nil
+}
+
+func newExpandNode(key string) *expandNode {
+	return &expandNode{
+		key:      key,
+		children: map[string]*expandNode{},
+		order:    []string{},
+	}
+}
+
+// insert puts the value into the tree creating
+// branches for the key parts starting from depth, see Expand().
+func (n *expandNode) insert(parts []string, depth int, key string, value interface{}, joinKey func(prefix, part string) string) error {
+	if n.leaf {
+		return NewErrKeyConflict(n.key, key)
+	}
+
+	child, ok := n.children[parts[depth]]
+	if !ok {
+		child = newExpandNode(joinKey(n.key, parts[depth]))
+		n.children[parts[depth]] = child
+		n.order = append(n.order, parts[depth])
+	}
+
+	if depth < len(parts)-1 {
+		return child.insert(parts, depth+1, key, value, joinKey)
+	}
+
+	if ok {
+		return NewErrKeyConflict(key, child.firstLeaf())
+	}
+	child.leaf = true
+	child.value = value
+
+	return nil
+}
+
+// firstLeaf returns the key of the first leaf in the branch.
+func (n *expandNode) firstLeaf() string {
+	if n.leaf {
+		return n.key
+	}
+	return n.children[n.order[0]].firstLeaf()
+}
+
+// mapping converts the branch into a map.
+func (n *expandNode) mapping() map[string]interface{} {
+	result := make(map[string]interface{}, len(n.children))
+	for k, child := range n.children {
+		result[k] = child.interfaceValue()
+	}
+
+	return result
+}
+
+// interfaceValue converts the node into a leaf value,
+// a slice or a map, see Expand().
+func (n *expandNode) interfaceValue() interface{} {
+	if n.leaf {
+		return n.value
+	}
+
+	for k := 0; k < len(n.children); k++ {
+		if _, ok := n.children[strconv.Itoa(k)]; !ok {
+			return n.mapping()
+		}
+	}
+
+	result := make([]interface{}, len(n.children))
+	for k := range result {
+		result[k] = n.children[strconv.Itoa(k)].interfaceValue()
+	}
+
+	return result
+}
+
+//
+
+// Expand creates a nested map from a flat map.
+// It uses Default Builder.
+func Expand(m map[string]interface{}) (map[string]interface{}, error) {
+	return Default.Expand(m)
+}
@@ -0,0 +1,142 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderExpand(t *testing.T) {
+	sample := map[string]interface{}{
+		"id":                              1,
+		"source":                          nil,
+		"tags.0":                          "a",
+		"tags.1":                          "b",
+		"codes.0":                         "a",
+		"codes.2":                         "c",
+		"scope.hosts.0.host":              "127.0.0.1",
+		"scope.request.headers.userAgent": "curl",
+	}
+
+	result, err := NewBuilder("key", ".").Expand(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"id":     1,
+			"source": nil,
+			"tags":   []interface{}{"a", "b"},
+			"codes": map[string]interface{}{
+				"0": "a",
+				"2": "c",
+			},
+			"scope": map[string]interface{}{
+				"hosts": []interface{}{
+					map[string]interface{}{"host": "127.0.0.1"},
+				},
+				"request": map[string]interface{}{
+					"headers": map[string]interface{}{
+						"userAgent": "curl",
+					},
+				},
+			},
+		},
+		result,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderExpandConflict(t *testing.T) {
+	sample := map[string]interface{}{
+		"a":   1,
+		"a.b": 2,
+	}
+
+	result, err := NewBuilder("key", ".").Expand(sample)
+	if err == nil {
+		t.Error("Key used as a leaf and as a branch should be reported as ErrKeyConflict")
+		return
+	}
+
+	if _, ok := err.(*ErrKeyConflict); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrKeyConflict, got '%T'",
+			err,
+		)
+	}
+
+	assert.Equal(
+		t,
+		(map[string]interface{})(nil),
+		result,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderExpandRootKey(t *testing.T) {
+	samples := []KeySyntax{JSONPointer, Brackets}
+
+	for _, sample := range samples {
+		b := NewBuilder("key", "")
+		b.KeySyntax = sample
+
+		result, err := b.Expand(map[string]interface{}{"": 1})
+		if err == nil {
+			t.Error("Key without parts should be reported as ErrInvalidKey")
+			return
+		}
+
+		if _, ok := err.(*ErrInvalidKey); !ok {
+			t.Errorf(
+				"Invalid error type, expected ErrInvalidKey, got '%T'",
+				err,
+			)
+		}
+
+		assert.Equal(
+			t,
+			(map[string]interface{})(nil),
+			result,
+			spew.Sdump(sample),
+		)
+	}
+}
+
+func TestBuilderExpandFlattenAnyRoundTrip(t *testing.T) {
+	sample := map[string]interface{}{
+		"tags": []interface{}{"a", map[string]interface{}{"b": "c"}},
+		"scope": map[string]interface{}{
+			"host": "127.0.0.1",
+		},
+	}
+	builder := NewBuilder("key", ".")
+
+	pairs, err := builder.FlattenAny(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	mapping := map[string]interface{}{}
+	for _, pair := range pairs {
+		mapping[pair.Key] = pair.Value
+	}
+
+	result, err := builder.Expand(mapping)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(mapping),
+	)
+}
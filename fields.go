@@ -0,0 +1,162 @@
+package flatstructs
+
+import (
+	"reflect"
+)
+
+// structField is a struct field which is visible
+// in the namespace of the struct.
+type structField struct {
+	reflect.StructField
+
+	tag fieldTag
+
+	// path is a Go path of the field, it includes
+	// names of the embedded structs for the promoted fields.
+	path string
+
+	// depth is a number of embedded structs
+	// this field is promoted through.
+	depth int
+}
+
+// structFields returns fields of the struct type in the order
+// of declaration, skipping unexported fields and fields
+// which are skipped by the tag.
+// When Builder.PromoteEmbedded is enabled fields of anonymous embedded structs
+// are promoted into the struct namespace, field Index
+// is the full index path from the struct in this case.
+func (b *Builder) structFields(reflectType reflect.Type) []structField {
+	fields := b.collectFields(
+		reflectType,
+		[]int{},
+		"",
+		0,
+		[]reflect.Type{reflectType},
+	)
+	if !b.PromoteEmbedded {
+		return fields
+	}
+
+	return dominantFields(fields)
+}
+
+// collectFields, see structFields().
+func (b *Builder) collectFields(reflectType reflect.Type, index []int, path string, depth int, parents []reflect.Type) []structField {
+	var (
+		fields    = []structField{}
+		field     reflect.StructField
+		fieldType reflect.Type
+		tag       fieldTag
+	)
+
+	for n := 0; n < reflectType.NumField(); n++ {
+		field = reflectType.Field(n)
+		field.Index = append(index[:len(index):len(index)], n)
+
+		tag = b.fieldTag(field)
+		if tag.skip {
+			continue
+		}
+
+		if b.isPromoted(field, tag, parents) {
+			fieldType = field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			fields = append(
+				fields,
+				b.collectFields(
+					fieldType,
+					field.Index,
+					joinFieldPath(path, field.Name),
+					depth+1,
+					append(parents[:len(parents):len(parents)], fieldType),
+				)...,
+			)
+			continue
+		}
+
+		if !isStructFieldExported(field) {
+			continue
+		}
+
+		fields = append(
+			fields,
+			structField{
+				StructField: field,
+				tag:         tag,
+				path:        joinFieldPath(path, field.Name),
+				depth:       depth,
+			},
+		)
+	}
+
+	return fields
+}
+
+// isPromoted reports whether fields of the anonymous
+// embedded struct field should be promoted, see structFields().
+// Like in encoding/json embedded struct with a name in the tag
+// is treated as a regular field.
+func (b *Builder) isPromoted(field reflect.StructField, tag fieldTag, parents []reflect.Type) bool {
+	if !b.PromoteEmbedded || !field.Anonymous || tag.named || tag.leaf || tag.inline {
+		return false
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		// Nil pointer to the unexported type could not be allocated.
+		if !isStructFieldExported(field) {
+			return false
+		}
+		fieldType = fieldType.Elem()
+	}
+
+	return fieldType.Kind() == reflect.Struct && !isTypeIn(fieldType, parents)
+}
+
+// dominantFields filters out fields which are shadowed
+// according to the Go rules for the promoted fields:
+// field with the least depth wins, fields with the same
+// name at the same depth are ambiguous and dropped
+// unless only one of them has a name in the tag.
+func dominantFields(fields []structField) []structField {
+	result := make([]structField, 0, len(fields))
+	for k := range fields {
+		if isDominantField(fields[k], fields) {
+			result = append(result, fields[k])
+		}
+	}
+
+	return result
+}
+
+// isDominantField, see dominantFields().
+func isDominantField(field structField, fields []structField) bool {
+	for _, other := range fields {
+		if other.tag.name != field.tag.name || isSameIndex(other.Index, field.Index) {
+			continue
+		}
+		if other.depth < field.depth {
+			return false
+		}
+		if other.depth == field.depth && !(field.tag.named && !other.tag.named) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isSameIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPromotingBuilder() *Builder {
+	builder := NewBuilder("key", ".")
+	builder.PromoteEmbedded = true
+	return builder
+}
+
+func TestBuilderKeysPromoteEmbedded(t *testing.T) {
+	type Headers struct {
+		UserAgent string
+	}
+	type Request struct {
+		Headers
+	}
+	type Connection struct {
+		Host string
+	}
+	type Scope struct {
+		Request
+		*Connection
+	}
+	type Event struct {
+		ID int
+		Scope
+	}
+	sample := Event{1, Scope{Request{Headers{"curl"}}, &Connection{"127.0.0.1"}}}
+
+	pairs, err := newPromotingBuilder().Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"ID", 1},
+			{"UserAgent", "curl"},
+			{"Host", "127.0.0.1"},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysPromoteEmbeddedShadowing(t *testing.T) {
+	type Inner struct {
+		Foo string
+		Bar string
+		Baz string
+	}
+	type Other struct {
+		Bar string
+		Baz string `key:"Baz"`
+	}
+	type Outer struct {
+		Inner
+		Other
+		Foo string
+	}
+	sample := Outer{Inner{"inner", "inner", "inner"}, Other{"other", "other"}, "outer"}
+
+	pairs, err := newPromotingBuilder().Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"Baz", "other"},
+			{"Foo", "outer"},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysPromoteEmbeddedTagged(t *testing.T) {
+	type Inner struct {
+		Foo string
+	}
+	type inner struct {
+		Bar string
+	}
+	type Outer struct {
+		Inner `key:"inner"`
+		inner
+	}
+	sample := Outer{Inner{"foo"}, inner{"bar"}}
+
+	keys, err := newPromotingBuilder().Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"inner.Foo", "Bar"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalPromoteEmbedded(t *testing.T) {
+	type Inner struct {
+		Foo string
+	}
+	type Outer struct {
+		*Inner
+		Bar string
+	}
+	sample := Outer{}
+
+	err := newPromotingBuilder().Unmarshal(
+		map[string]interface{}{
+			"Foo": "foo",
+			"Bar": "bar",
+		},
+		&sample,
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		Outer{&Inner{"foo"}, "bar"},
+		sample,
+		spew.Sdump(sample),
+	)
+}
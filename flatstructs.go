@@ -2,7 +2,7 @@ package flatstructs
 
 import (
 	"reflect"
-	"strings"
+	"sync"
 )
 
 var (
@@ -15,102 +15,127 @@ var (
 // to look up struct field names in and
 // a keyDelimiter which delimits key parts
 // when converting nested struct into flat.
+//
+// Builder caches the flat layout of every type it meets,
+// so it should not be modified after the first use.
+// It is safe for concurrent use.
 type Builder struct {
 	Tag          string
 	KeyDelimiter string
+
+	// KeySyntax joins key parts into keys when it is set,
+	// KeyDelimiter is ignored in this case.
+	// It allows key parts which contain the delimiter
+	// to be parsed back, see DelimitedSyntax, JSONPointer and Brackets.
+	KeySyntax KeySyntax
+
+	// FallbackTags are tag names which are consulted in order
+	// for the fields which have no Tag, the first tag the field has
+	// is used with its options, so structs which already have
+	// json or yaml tags do not need the additional tag.
+	FallbackTags []string
+
+	// PromoteEmbedded enables promotion of the anonymous
+	// embedded struct fields into the parent namespace
+	// like encoding/json does, so keys match the way
+	// fields are accessed in the code.
+	// Go rules for the field shadowing are applied on conflicts.
+	PromoteEmbedded bool
+
+	// ExpandSlices enables flattening of the slices and arrays
+	// with the element index as a key part(Items.0.Name),
+	// otherwise slice is represented as a single leaf.
+	// Slices and arrays of bytes are always represented as a single leaf.
+	ExpandSlices bool
+
+	// ExpandMaps enables flattening of the maps with string keys
+	// or keys implementing encoding.TextMarshaler using
+	// map key as a key part(Labels.app), keys are sorted,
+	// otherwise map is represented as a single leaf.
+	ExpandMaps bool
+
+	// Strict enables the check for the key collisions
+	// which is done once per type, every call with the
+	// type which has colliding keys will return ErrKeyCollision.
+	// Collisions in the slices and maps could be detected only at runtime,
+	// Map() reports them regardless of this setting.
+	Strict bool
+
+	// MaxDepth limits the number of key parts,
+	// leafs which are nested deeper are reported as ErrMaxDepth.
+	// Zero means no limit.
+	// Cycles in the pointers are always reported as ErrCycle.
+	MaxDepth int
+
+	// NameMapper converts the names of the fields
+	// which have no name in the tag into key parts,
+	// see SnakeCase, KebabCase, ScreamingSnakeCase and LowerCamelCase.
+	// Field names are used as is if it is nil.
+	NameMapper NameMapper
+
+	// LeafTypes are types which are always represented
+	// as a single leaf even if they have exported fields.
+	// Interface types match every type which implements them
+	// with a value or a pointer receiver.
+	// NewBuilder() sets it to the DefaultLeafTypes.
+	LeafTypes []reflect.Type
+
+	// ScanStrictness controls which mismatches between
+	// the columns and the leafs are reported by ScanRows().
+	ScanStrictness ScanStrictness
+
+	// ScanCaseSensitive disables case-insensitive
+	// matching of the columns to the keys in ScanRows().
+	ScanCaseSensitive bool
+
+	// LookupEnv looks up the environment variables in LoadEnv(),
+	// os.LookupEnv is used if it is nil.
+	LookupEnv func(name string) (string, bool)
+
+	plansLock sync.RWMutex
+	plans     map[reflect.Type]*plan
 }
 
-func (b *Builder) fieldName(field reflect.StructField) string {
-	name := field.Tag.Get(b.Tag)
-	if name == "" {
-		return field.Name
-	}
-	return name
+// Pair is a flat key with its value.
+type Pair struct {
+	Key   string
+	Value interface{}
 }
 
 // Keys creates a flat slice of keys from a nested structure exported fields.
+// Chains of pointers are dereferenced, interface fields are
+// flattened using the concrete type of the value they hold.
 func (b *Builder) Keys(v interface{}) ([]string, error) {
 	err := checkValue(v)
 	if err != nil {
 		return nil, err
 	}
 
-	return b.toKeys(v, []string{})
+	return b.toKeys(v)
 }
 
 // toKeys, see Keys().
-func (b *Builder) toKeys(v interface{}, prefix []string) ([]string, error) {
-	var (
-		reflectType  reflect.Type  = indirectType(reflect.TypeOf(v))
-		reflectValue reflect.Value = indirectValue(reflect.ValueOf(v))
-		field        reflect.StructField
-		fieldValue   reflect.Value
-		key          string
-		keys         []string
-		err          error
-	)
-
-	if !reflectValue.IsValid() {
-		return nil, NewErrInvalid(v)
+func (b *Builder) toKeys(v interface{}) ([]string, error) {
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return nil, err
 	}
 
-	err = checkStruct(reflectValue)
+	p, err := b.plan(reflectValue.Type())
 	if err != nil {
 		return nil, err
 	}
 
-	keys = []string{}
-
-	for n := 0; n < reflectValue.NumField(); n++ {
-		field = reflectType.Field(n)
-		if !isStructFieldExported(field) {
-			continue
-		}
-
-		fieldValue = indirectValue(reflectValue.Field(n))
-		if !fieldValue.CanAddr() {
-			continue
-		}
-
-		key = b.fieldName(field)
-		switch fieldValue.Kind() {
-		case reflect.Struct:
-			var (
-				subKeys []string
-			)
-			subKeys, err = b.toKeys(
-				fieldValue.Addr().Interface(),
-				append(prefix, key),
-			)
-			if err != nil {
-				return nil, err
-			}
-
-			if len(subKeys) == 0 {
-				keys = append(
-					keys,
-					strings.Join(
-						append(prefix, key),
-						b.KeyDelimiter,
-					),
-				)
-			} else {
-				for _, v := range subKeys {
-					keys = append(
-						keys,
-						v,
-					)
-				}
-			}
-		default:
-			keys = append(
-				keys,
-				strings.Join(
-					append(prefix, key),
-					b.KeyDelimiter,
-				),
-			)
-		}
+	keys := make([]string, 0, len(p.fields))
+	err = b.walkRoot(
+		rootNode(reflect.ValueOf(v)),
+		func(n node) error {
+			keys = append(keys, n.key)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
 	}
 
 	return keys, nil
@@ -128,79 +153,67 @@ func (b *Builder) Values(v interface{}) ([]interface{}, error) {
 
 // toValues, see Values().
 func (b *Builder) toValues(v interface{}) ([]interface{}, error) {
-	var (
-		reflectType  reflect.Type  = indirectType(reflect.TypeOf(v))
-		reflectValue reflect.Value = indirectValue(reflect.ValueOf(v))
-		field        reflect.StructField
-		fieldValue   reflect.Value
-		values       []interface{}
-		err          error
-	)
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
 
-	if !reflectValue.IsValid() {
-		return nil, NewErrInvalid(v)
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return nil, err
 	}
 
-	err = checkStruct(reflectValue)
+	values := make([]interface{}, 0, len(p.fields))
+	err = b.walkRoot(
+		rootNode(reflect.ValueOf(v)),
+		func(n node) error {
+			values = append(values, n.value.Interface())
+			return nil
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	values = []interface{}{}
+	return values, nil
+}
 
-	for n := 0; n < reflectValue.NumField(); n++ {
-		field = reflectType.Field(n)
-		if !isStructFieldExported(field) {
-			continue
-		}
+// Pairs creates a flat slice of Keys(): Values() pairs from a nested structure
+// preserving the order of the fields.
+func (b *Builder) Pairs(v interface{}) ([]Pair, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
 
-		fieldValue = indirectValue(reflectValue.Field(n))
-		if !fieldValue.CanAddr() {
-			continue
-		}
+	return b.toPairs(v)
+}
 
-		if !fieldValue.CanInterface() {
-			values = append(
-				values,
-				nil,
-			)
-			continue
-		}
+// toPairs, see Pairs().
+func (b *Builder) toPairs(v interface{}) ([]Pair, error) {
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
 
-		switch fieldValue.Kind() {
-		case reflect.Struct:
-			var (
-				subValues []interface{}
-			)
-			subValues, err = b.toValues(
-				fieldValue.Addr().Interface(),
-			)
-			if err != nil {
-				return nil, err
-			}
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return nil, err
+	}
 
-			if len(subValues) == 0 {
-				values = append(
-					values,
-					fieldValue.Interface(),
-				)
-			} else {
-				for _, v := range subValues {
-					values = append(
-						values,
-						v,
-					)
-				}
-			}
-		default:
-			values = append(
-				values,
-				fieldValue.Interface(),
-			)
-		}
+	pairs := make([]Pair, 0, len(p.fields))
+	err = b.walkRoot(
+		rootNode(reflect.ValueOf(v)),
+		func(n node) error {
+			pairs = append(pairs, Pair{n.key, n.value.Interface()})
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	return values, nil
+	return pairs, nil
 }
 
 // Map creates a map with Keys(): Values() from a nested structure.
@@ -215,29 +228,68 @@ func (b *Builder) Map(v interface{}) (map[string]interface{}, error) {
 
 // toMap, see Map().
 func (b *Builder) toMap(v interface{}) (map[string]interface{}, error) {
-	var (
-		result = make(map[string]interface{})
-		keys   []string
-		values []interface{}
-		err    error
-	)
-
-	keys, err = b.Keys(v)
+	reflectValue, err := structValue(v)
 	if err != nil {
 		return nil, err
 	}
-	values, err = b.Values(v)
+
+	p, err := b.plan(reflectValue.Type())
 	if err != nil {
 		return nil, err
 	}
 
-	for k, _ := range keys {
-		result[keys[k]] = values[k]
+	result := make(map[string]interface{}, len(p.fields))
+	err = b.walkRoot(
+		rootNode(reflect.ValueOf(v)),
+		func(n node) error {
+			if _, ok := result[n.key]; ok {
+				return b.keyCollision(v, n)
+			}
+			result[n.key] = n.value.Interface()
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
+// keyCollision creates an error for the node which key
+// was already reported by the walk() for another field.
+func (b *Builder) keyCollision(v interface{}, collision node) error {
+	var (
+		field string
+	)
+
+	err := b.walkRoot(
+		rootNode(reflect.ValueOf(v)),
+		func(n node) error {
+			if n.key == collision.key {
+				field = n.fieldPath()
+				return errStopWalk
+			}
+			return nil
+		},
+	)
+	if err != nil && err != errStopWalk {
+		return err
+	}
+
+	return NewErrKeyCollision(collision.key, field, collision.fieldPath())
+}
+
+// structValue returns a struct value v points to.
+func structValue(v interface{}) (reflect.Value, error) {
+	reflectValue := indirectValue(reflect.ValueOf(v))
+	if !reflectValue.IsValid() {
+		return reflectValue, NewErrInvalid(v)
+	}
+
+	return reflectValue, checkStruct(reflectValue)
+}
+
 func checkValue(v interface{}) error {
 	val := reflect.ValueOf(v)
 	if val.Kind() != reflect.Ptr {
@@ -266,16 +318,66 @@ func isStructFieldExported(field reflect.StructField) bool {
 	return field.PkgPath == ""
 }
 
+// isNilValue reports whether reflectValue is a nil pointer or a nil map.
+func isNilValue(reflectValue reflect.Value) bool {
+	switch reflectValue.Kind() {
+	case reflect.Ptr, reflect.Map:
+		return reflectValue.IsNil()
+	default:
+		return false
+	}
+}
+
+// isZeroValue reports whether reflectValue is a zero value of its type.
+// Like in encoding/json empty slices and maps are considered zero.
+func isZeroValue(reflectValue reflect.Value) bool {
+	switch reflectValue.Kind() {
+	case reflect.Bool:
+		return !reflectValue.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflectValue.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflectValue.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return reflectValue.Float() == 0
+	case reflect.Complex64, reflect.Complex128:
+		return reflectValue.Complex() == 0
+	case reflect.String, reflect.Slice, reflect.Map:
+		return reflectValue.Len() == 0
+	case reflect.Ptr, reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return reflectValue.IsNil()
+	case reflect.Array:
+		for n := 0; n < reflectValue.Len(); n++ {
+			if !isZeroValue(reflectValue.Index(n)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for n := 0; n < reflectValue.NumField(); n++ {
+			if !isZeroValue(reflectValue.Field(n)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// indirectValue dereferences the chain of pointers,
+// invalid value is returned if one of them is nil.
 func indirectValue(reflectValue reflect.Value) reflect.Value {
-	if reflectValue.Kind() == reflect.Ptr {
-		return reflectValue.Elem()
+	for reflectValue.Kind() == reflect.Ptr {
+		reflectValue = reflectValue.Elem()
 	}
 	return reflectValue
 }
 
+// indirectType returns a type which is behind the chain of pointers.
 func indirectType(reflectType reflect.Type) reflect.Type {
-	if reflectType.Kind() == reflect.Ptr || reflectType.Kind() == reflect.Slice {
-		return reflectType.Elem()
+	for reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
 	}
 	return reflectType
 }
@@ -294,6 +396,12 @@ func Values(v interface{}) ([]interface{}, error) {
 	return Default.Values(v)
 }
 
+// Pairs creates a flat slice of Keys(): Values() pairs from a nested structure.
+// It uses Default Builder.
+func Pairs(v interface{}) ([]Pair, error) {
+	return Default.Pairs(v)
+}
+
 // Map creates a map with Keys(): Values() from a nested structure.
 // It uses Default Builder.
 func Map(v interface{}) (map[string]interface{}, error) {
@@ -306,5 +414,9 @@ func Map(v interface{}) (map[string]interface{}, error) {
 // parameterized tag name and keyDelimiter which delimits key parts
 // when nested struct converted to flat.
 func NewBuilder(tag, keyDelimiter string) *Builder {
-	return &Builder{tag, keyDelimiter}
+	return &Builder{
+		Tag:          tag,
+		KeyDelimiter: keyDelimiter,
+		LeafTypes:    append([]reflect.Type{}, DefaultLeafTypes...),
+	}
 }
@@ -75,6 +75,37 @@ func BenchmarkBuilderKeysNested(b *testing.B) {
 	}
 }
 
+func BenchmarkBuilderKeysNestedUncached(b *testing.B) {
+	type Flat struct {
+		Foo          int
+		Bar          int
+		Baz          int
+		LongerBurger int
+	}
+	type Nested struct {
+		Foo *Flat
+		Bar *Flat
+		Baz Flat
+		Daz Flat
+	}
+	nested := &Nested{
+		&Flat{1, 2, 3, 4},
+		&Flat{1, 2, 3, 4},
+		Flat{1, 2, 3, 4},
+		Flat{1, 2, 3, 4},
+	}
+	var (
+		err error
+	)
+	for k := 0; k < b.N; k++ {
+		_, err = NewBuilder("key", "").Keys(nested)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+	}
+}
+
 func BenchmarkBuilderKeysNestedWithData(b *testing.B) {
 	type Flat struct {
 		Foo          int
@@ -451,3 +482,34 @@ func BenchmarkBuilderMapNested(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkBuilderPairsNested(b *testing.B) {
+	type Flat struct {
+		Foo          int
+		Bar          int
+		Baz          int
+		LongerBurger int
+	}
+	type Nested struct {
+		Foo *Flat
+		Bar *Flat
+		Baz *Flat
+		Daz *Flat
+	}
+	nested := &Nested{
+		&Flat{1, 2, 3, 4},
+		&Flat{1, 2, 3, 4},
+		&Flat{1, 2, 3, 4},
+		&Flat{1, 2, 3, 4},
+	}
+	var (
+		err error
+	)
+	for k := 0; k < b.N; k++ {
+		_, err = Pairs(nested)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package flatstructs
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -696,3 +697,312 @@ func TestBuilderMap(t *testing.T) {
 		spew.Sdump(sample),
 	)
 }
+
+func TestBuilderPairs(t *testing.T) {
+	type Flat struct {
+		baz  string
+		Jazz string
+	}
+	type Nested struct {
+		Foo string
+		xyz string
+		Bar *Flat
+		Daz *Flat
+		Baz string
+	}
+	sample := Nested{
+		"foo",
+		"xyz",
+		&Flat{"baz", "jazz"},
+		nil,
+		"baz",
+	}
+
+	pairs, err := NewBuilder("key", ".").Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"Foo", "foo"},
+			{"Bar.Jazz", "jazz"},
+			{"Baz", "baz"},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderPairsExpandSlices(t *testing.T) {
+	type Item struct {
+		Name string
+	}
+	type Nested struct {
+		Items  []Item
+		Ptrs   []*Item
+		Tags   [2]string
+		Matrix [][]int
+		Data   []byte
+		Empty  []string
+	}
+	sample := Nested{
+		Items:  []Item{{"foo"}, {"bar"}},
+		Ptrs:   []*Item{nil, {"baz"}},
+		Tags:   [2]string{"a", "b"},
+		Matrix: [][]int{{1}, {2, 3}},
+		Data:   []byte("data"),
+	}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+
+	pairs, err := builder.Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"Items.0.Name", "foo"},
+			{"Items.1.Name", "bar"},
+			{"Ptrs.1.Name", "baz"},
+			{"Tags.0", "a"},
+			{"Tags.1", "b"},
+			{"Matrix.0.0", 1},
+			{"Matrix.1.0", 2},
+			{"Matrix.1.1", 3},
+			{"Data", []byte("data")},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderValuesSlicesLeaf(t *testing.T) {
+	type Item struct {
+		Name string
+	}
+	type Nested struct {
+		Items []Item
+	}
+	sample := Nested{[]Item{{"foo"}}}
+
+	values, err := NewBuilder("key", ".").Values(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]interface{}{[]Item{{"foo"}}},
+		values,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderMapKeyCollision(t *testing.T) {
+	type A struct {
+		BC int
+	}
+	type AB struct {
+		C int
+	}
+	type Nested struct {
+		A  A
+		AB AB
+	}
+	sample := Nested{A{1}, AB{2}}
+
+	mapping, err := Map(&sample)
+	if err == nil {
+		t.Error("Colliding keys should be reported as ErrKeyCollision")
+		return
+	}
+
+	assert.Equal(
+		t,
+		&ErrKeyCollision{"ABC", "A.BC", "AB.C"},
+		err,
+		spew.Sdump(sample),
+	)
+
+	assert.Equal(
+		t,
+		(map[string]interface{})(nil),
+		mapping,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderMapKeyCollisionSlice(t *testing.T) {
+	type Nested struct {
+		Items  []int
+		Items0 int
+	}
+	sample := Nested{[]int{1}, 2}
+
+	builder := NewBuilder("key", "")
+	builder.ExpandSlices = true
+
+	_, err := builder.Map(&sample)
+
+	assert.Equal(
+		t,
+		&ErrKeyCollision{"Items0", "Items[0]", "Items0"},
+		err,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysStrictKeyCollision(t *testing.T) {
+	type A struct {
+		BC int
+	}
+	type Nested struct {
+		A   *A
+		ABC int
+	}
+	sample := Nested{nil, 2}
+
+	keys, err := Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"ABC"},
+		keys,
+		spew.Sdump(sample),
+	)
+
+	builder := NewBuilder("key", "")
+	builder.Strict = true
+
+	keys, err = builder.Keys(&sample)
+
+	assert.Equal(
+		t,
+		&ErrKeyCollision{"ABC", "A.BC", "ABC"},
+		err,
+		spew.Sdump(sample),
+	)
+
+	assert.Equal(
+		t,
+		([]string)(nil),
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderPairsNestedPtrPtr(t *testing.T) {
+	type Config struct {
+		Host string
+	}
+	type Nested struct {
+		Config **Config
+		Port   **int
+	}
+	var (
+		config = &Config{"localhost"}
+		port   = 1337
+		portp  = &port
+	)
+	sample := Nested{&config, &portp}
+
+	pairs, err := Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"ConfigHost", "localhost"},
+			{"Port", 1337},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderPairsInterface(t *testing.T) {
+	type Flat struct {
+		Foo string
+	}
+	type Nested struct {
+		Value   interface{}
+		Pointer interface{}
+		Scalar  interface{}
+		Nil     interface{}
+		Err     error
+	}
+	sample := Nested{
+		Value:   Flat{"foo"},
+		Pointer: &Flat{"bar"},
+		Scalar:  1,
+		Err:     errors.New("failed"),
+	}
+
+	pairs, err := NewBuilder("key", ".").Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"Value.Foo", "foo"},
+			{"Pointer.Foo", "bar"},
+			{"Scalar", 1},
+			{"Nil", nil},
+			{"Err", sample.Err},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysInterfaceConcreteType(t *testing.T) {
+	type Foo struct {
+		Foo string
+	}
+	type Bar struct {
+		Bar string
+		Baz string
+	}
+	type Nested struct {
+		Value interface{}
+	}
+
+	for _, sample := range []struct {
+		value    Nested
+		expected []string
+	}{
+		{Nested{Foo{}}, []string{"Value.Foo"}},
+		{Nested{&Bar{}}, []string{"Value.Bar", "Value.Baz"}},
+	} {
+		keys, err := NewBuilder("key", ".").Keys(&sample.value)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(
+			t,
+			sample.expected,
+			keys,
+			spew.Sdump(sample),
+		)
+	}
+}
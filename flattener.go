@@ -0,0 +1,181 @@
+package flatstructs
+
+import (
+	"reflect"
+	"strconv"
+)
+
+var (
+	flattenerType   = reflect.TypeOf((*Flattener)(nil)).Elem()
+	unflattenerType = reflect.TypeOf((*Unflattener)(nil)).Elem()
+)
+
+// Flattener is implemented by the types which control
+// their own flat representation.
+// FlattenInto should call emit for every leaf of the value,
+// key is a sequence of key parts relative to the value,
+// they are joined with the Builder.KeySyntax or Builder.KeyDelimiter and prefixed
+// with the key of the field which holds the value.
+// Empty key means the value is represented as a single leaf.
+type Flattener interface {
+	FlattenInto(emit func(key []string, v interface{}))
+}
+
+// Unflattener is a counterpart of the Flattener which
+// is used by Unmarshal().
+// UnflattenFrom should restore the value using lookup
+// which returns a value for the key(relative to the value, see Flattener)
+// and reports whether the key is present.
+// Unflattener is called only on the addressable values,
+// nil pointers are allocated and assigned only if
+// at least one key was looked up successfully.
+type Unflattener interface {
+	UnflattenFrom(lookup func(key []string) (interface{}, bool)) error
+}
+
+// isFlattenerType reports whether reflectType(or the type
+// behind the chain of pointers) implements Flattener or Unflattener.
+// Interfaces are checked at runtime using their values.
+func isFlattenerType(reflectType reflect.Type) bool {
+	reflectType = indirectType(reflectType)
+	if reflectType.Kind() == reflect.Interface {
+		return false
+	}
+
+	return reflectType.Implements(flattenerType) ||
+		reflect.PtrTo(reflectType).Implements(flattenerType) ||
+		isUnflattenerType(reflectType)
+}
+
+// isUnflattenerType reports whether reflectType(or the type
+// behind the chain of pointers) implements Unflattener.
+func isUnflattenerType(reflectType reflect.Type) bool {
+	reflectType = indirectType(reflectType)
+	if reflectType.Kind() == reflect.Interface {
+		return false
+	}
+
+	return reflectType.Implements(unflattenerType) ||
+		reflect.PtrTo(reflectType).Implements(unflattenerType)
+}
+
+// flattener returns a Flattener implemented by the value
+// or by a pointer to the value if it is addressable.
+func flattener(reflectValue reflect.Value) (Flattener, bool) {
+	if !reflectValue.IsValid() || isNilValue(reflectValue) {
+		return nil, false
+	}
+
+	if reflectValue.Type().Implements(flattenerType) {
+		return reflectValue.Interface().(Flattener), true
+	}
+	if reflectValue.CanAddr() && reflectValue.Addr().Type().Implements(flattenerType) {
+		return reflectValue.Addr().Interface().(Flattener), true
+	}
+
+	return nil, false
+}
+
+// walkFlattener calls fn for every leaf emitted by the Flattener,
+// leafs are nested into n, see walk().
+// Leafs are collected before fn is called, so fn is not
+// captured by the emit closure and does not escape.
+func (b *Builder) walkFlattener(n node, f Flattener, fn func(node) error) error {
+	pushed := n.push()
+	defer n.pop(pushed)
+
+	var (
+		leafs = []node{}
+		err   error
+	)
+
+	f.FlattenInto(func(key []string, v interface{}) {
+		if err != nil {
+			return
+		}
+
+		leaf := n
+		leaf.field = ""
+		leaf.depth = n.depth + len(key)
+		if n.path != nil {
+			leaf.path = n.path.join(key...)
+		}
+		for _, part := range key {
+			leaf.key = b.joinKey(leaf.key, part)
+			leaf.field += "[" + strconv.Quote(part) + "]"
+		}
+		if b.MaxDepth > 0 && leaf.depth > b.MaxDepth {
+			err = NewErrMaxDepth(leaf.fieldPath(), b.MaxDepth)
+			return
+		}
+
+		if v == nil {
+			// Keep the nil leaf valid, so it is reported as nil.
+			leaf.value = reflect.ValueOf(&v).Elem()
+		} else {
+			leaf.value = reflect.ValueOf(v)
+		}
+		leafs = append(leafs, leaf)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, leaf := range leafs {
+		err = fn(leaf)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fromMapUnflattener assigns the value implementing Unflattener
+// using the keys nested into the key, see fromMapValue().
+func (b *Builder) fromMapUnflattener(m map[string]interface{}, reflectValue reflect.Value, key string) (bool, error) {
+	return unflatten(reflectValue, func(parts []string) (interface{}, bool) {
+		k := key
+		for _, part := range parts {
+			k = b.joinKey(k, part)
+		}
+
+		value, ok := m[k]
+		return value, ok
+	})
+}
+
+// unflatten assigns the value implementing Unflattener
+// using the lookup, nil pointers are allocated and assigned
+// only if at least one key was looked up successfully.
+// It reports whether at least one key was found.
+func unflatten(reflectValue reflect.Value, lookup func(parts []string) (interface{}, bool)) (bool, error) {
+	if reflectValue.Kind() == reflect.Ptr {
+		if !reflectValue.IsNil() {
+			return unflatten(reflectValue.Elem(), lookup)
+		}
+
+		nested := reflect.New(reflectValue.Type().Elem())
+		ok, err := unflatten(nested.Elem(), lookup)
+		if err != nil || !ok {
+			return false, err
+		}
+		reflectValue.Set(nested)
+		return true, nil
+	}
+
+	var (
+		u     = reflectValue.Addr().Interface().(Unflattener)
+		found bool
+	)
+	err := u.UnflattenFrom(func(parts []string) (interface{}, bool) {
+		value, ok := lookup(parts)
+		found = found || ok
+		return value, ok
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
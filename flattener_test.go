@@ -0,0 +1,134 @@
+package flatstructs
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+type flattenerTestMoney struct {
+	cents    int64
+	currency string
+}
+
+func (m flattenerTestMoney) FlattenInto(emit func(key []string, v interface{})) {
+	emit([]string{"amount"}, fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100))
+	emit([]string{"currency"}, m.currency)
+}
+
+func (m *flattenerTestMoney) UnflattenFrom(lookup func(key []string) (interface{}, bool)) error {
+	var (
+		units, cents int64
+	)
+
+	amount, ok := lookup([]string{"amount"})
+	if ok {
+		_, err := fmt.Sscanf(amount.(string), "%d.%d", &units, &cents)
+		if err != nil {
+			return err
+		}
+		m.cents = units*100 + cents
+	}
+	currency, ok := lookup([]string{"currency"})
+	if ok {
+		m.currency = currency.(string)
+	}
+
+	return nil
+}
+
+type flattenerTestID struct {
+	id      string
+	version int
+}
+
+func (id *flattenerTestID) FlattenInto(emit func(key []string, v interface{})) {
+	emit(nil, id.id+"@"+strconv.Itoa(id.version))
+}
+
+func TestBuilderPairsFlattener(t *testing.T) {
+	type Order struct {
+		ID    flattenerTestID
+		Price flattenerTestMoney
+		Tax   *flattenerTestMoney
+		Total *flattenerTestMoney
+	}
+	sample := Order{
+		ID:    flattenerTestID{"order", 2},
+		Price: flattenerTestMoney{1050, "USD"},
+		Tax:   &flattenerTestMoney{105, "USD"},
+	}
+
+	pairs, err := NewBuilder("key", ".").Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"ID", "order@2"},
+			{"Price.amount", "10.50"},
+			{"Price.currency", "USD"},
+			{"Tax.amount", "1.05"},
+			{"Tax.currency", "USD"},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysFlattenerRoot(t *testing.T) {
+	sample := flattenerTestMoney{100, "EUR"}
+
+	keys, err := NewBuilder("key", ".").Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"amount", "currency"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalUnflattener(t *testing.T) {
+	type Order struct {
+		Price flattenerTestMoney
+		Tax   *flattenerTestMoney
+		Total *flattenerTestMoney
+	}
+	sample := Order{
+		Price: flattenerTestMoney{1050, "USD"},
+		Tax:   &flattenerTestMoney{105, "USD"},
+	}
+
+	builder := NewBuilder("key", ".")
+
+	mapping, err := builder.Map(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result := Order{}
+	err = builder.Unmarshal(mapping, &result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(mapping),
+	)
+}
@@ -0,0 +1,39 @@
+package flatstructs
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+var (
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	// DefaultLeafTypes are leaf types of the Builder created with NewBuilder().
+	// Types which could represent themselves as a single value(time.Time,
+	// sql.NullString and so on) are not flattened.
+	DefaultLeafTypes = []reflect.Type{
+		textMarshalerType,
+		valuerType,
+	}
+)
+
+// isLeafType reports whether values of the type are always
+// represented as a single leaf, see Builder.LeafTypes.
+// Pointers to the leaf types are leafs too.
+func (b *Builder) isLeafType(reflectType reflect.Type) bool {
+	reflectType = indirectType(reflectType)
+
+	for _, leafType := range b.LeafTypes {
+		if reflectType == leafType {
+			return true
+		}
+		if leafType.Kind() != reflect.Interface {
+			continue
+		}
+		if reflectType.Implements(leafType) || reflect.PtrTo(reflectType).Implements(leafType) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,120 @@
+package flatstructs
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+type leafTestPoint struct {
+	X int
+	Y int
+}
+
+func (p leafTestPoint) String() string {
+	return fmt.Sprintf("%d:%d", p.X, p.Y)
+}
+
+func TestBuilderPairsLeafTypes(t *testing.T) {
+	type Nested struct {
+		Name      sql.NullString
+		Count     *sql.NullInt64
+		CreatedAt time.Time
+		Point     leafTestPoint
+	}
+	sample := Nested{
+		Name:      sql.NullString{String: "foo", Valid: true},
+		Count:     &sql.NullInt64{Int64: 1, Valid: true},
+		CreatedAt: time.Unix(0, 0).UTC(),
+		Point:     leafTestPoint{1, 2},
+	}
+
+	pairs, err := NewBuilder("key", ".").Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"Name", sample.Name},
+			{"Count", *sample.Count},
+			{"CreatedAt", sample.CreatedAt},
+			{"Point.X", 1},
+			{"Point.Y", 2},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysLeafTypesCustom(t *testing.T) {
+	type Flat struct {
+		Foo string
+	}
+	type Nested struct {
+		Name  sql.NullString
+		Flat  Flat
+		Point leafTestPoint
+		Value interface{}
+	}
+	sample := Nested{Value: leafTestPoint{}}
+
+	builder := NewBuilder("key", ".")
+	builder.LeafTypes = []reflect.Type{
+		reflect.TypeOf(Flat{}),
+		reflect.TypeOf((*fmt.Stringer)(nil)).Elem(),
+	}
+
+	keys, err := builder.Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"Name.String", "Name.Valid", "Flat", "Point", "Value"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalLeafTypes(t *testing.T) {
+	type Nested struct {
+		Name  sql.NullString
+		Count *sql.NullInt64
+	}
+	sample := Nested{
+		Name:  sql.NullString{String: "foo", Valid: true},
+		Count: &sql.NullInt64{Int64: 1, Valid: true},
+	}
+
+	builder := NewBuilder("key", ".")
+
+	mapping, err := builder.Map(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result := Nested{}
+	err = builder.Unmarshal(mapping, &result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(mapping),
+	)
+}
@@ -0,0 +1,100 @@
+package flatstructs
+
+import (
+	"encoding"
+	"reflect"
+	"sort"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// mapEntry is a map value with its key formatted as a key part.
+type mapEntry struct {
+	key   string
+	value reflect.Value
+}
+
+// mapEntries implements sort.Interface ordering entries by key.
+type mapEntries []mapEntry
+
+func (e mapEntries) Len() int           { return len(e) }
+func (e mapEntries) Less(i, j int) bool { return e[i].key < e[j].key }
+func (e mapEntries) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+// isExpandableMapType reports whether map keys could be
+// used as key parts, this is true for the maps with string
+// keys or keys implementing encoding.TextMarshaler.
+func isExpandableMapType(reflectType reflect.Type) bool {
+	if reflectType.Kind() != reflect.Map {
+		return false
+	}
+
+	keyType := reflectType.Key()
+	return keyType.Kind() == reflect.String || keyType.Implements(textMarshalerType)
+}
+
+// sortedMapEntries returns entries of the map sorted by formatted key,
+// so the map is flattened in the deterministic order.
+func sortedMapEntries(reflectValue reflect.Value) ([]mapEntry, error) {
+	var (
+		entries = make([]mapEntry, 0, reflectValue.Len())
+		key     string
+		err     error
+	)
+
+	for _, k := range reflectValue.MapKeys() {
+		key, err = formatMapKey(k)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(
+			entries,
+			mapEntry{key, reflectValue.MapIndex(k)},
+		)
+	}
+
+	sort.Sort(mapEntries(entries))
+
+	return entries, nil
+}
+
+// formatMapKey formats the map key as a key part,
+// like in encoding/json string keys are used as is,
+// other keys are formatted with encoding.TextMarshaler.
+func formatMapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+
+	buf, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// parseMapKey is the reverse of formatMapKey(),
+// keys implementing encoding.TextUnmarshaler
+// are parsed with it.
+// It reports whether map key type could be parsed from string.
+func parseMapKey(reflectType reflect.Type, key string) (reflect.Value, bool, error) {
+	if reflectType.Kind() == reflect.String {
+		return reflect.ValueOf(key).Convert(reflectType), true, nil
+	}
+
+	if !reflect.PtrTo(reflectType).Implements(textUnmarshalerType) {
+		return reflect.Value{}, false, nil
+	}
+
+	k := reflect.New(reflectType)
+	err := k.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key))
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+
+	return k.Elem(), true, nil
+}
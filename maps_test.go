@@ -0,0 +1,149 @@
+package flatstructs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapTestKey struct {
+	Namespace string
+	Name      string
+}
+
+func (k mapTestKey) MarshalText() ([]byte, error) {
+	return []byte(k.Namespace + "/" + k.Name), nil
+}
+
+func (k *mapTestKey) UnmarshalText(buf []byte) error {
+	parts := strings.SplitN(string(buf), "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("Invalid key '%s'", buf)
+	}
+	k.Namespace, k.Name = parts[0], parts[1]
+	return nil
+}
+
+func newMapBuilder() *Builder {
+	builder := NewBuilder("key", ".")
+	builder.ExpandMaps = true
+	return builder
+}
+
+func TestBuilderPairsExpandMaps(t *testing.T) {
+	type Annotation struct {
+		Value string
+	}
+	type Label string
+	type Nested struct {
+		Labels      map[Label]string
+		Annotations map[string]*Annotation
+		Refs        map[mapTestKey]int
+		Counts      map[int]int
+	}
+	sample := Nested{
+		Labels: map[Label]string{
+			"tier": "backend",
+			"app":  "flatstructs",
+		},
+		Annotations: map[string]*Annotation{
+			"owner": {"corpix"},
+			"empty": nil,
+		},
+		Refs: map[mapTestKey]int{
+			{"default", "b"}: 2,
+			{"default", "a"}: 1,
+		},
+		Counts: map[int]int{1: 1},
+	}
+
+	pairs, err := newMapBuilder().Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"Labels.app", "flatstructs"},
+			{"Labels.tier", "backend"},
+			{"Annotations.owner.Value", "corpix"},
+			{"Refs.default/a", 1},
+			{"Refs.default/b", 2},
+			{"Counts", map[int]int{1: 1}},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderValuesMapsLeaf(t *testing.T) {
+	type Nested struct {
+		Labels map[string]string
+	}
+	sample := Nested{map[string]string{"app": "flatstructs"}}
+
+	values, err := NewBuilder("key", ".").Values(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]interface{}{map[string]string{"app": "flatstructs"}},
+		values,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalExpandMaps(t *testing.T) {
+	type Annotation struct {
+		Value string
+	}
+	type Nested struct {
+		Labels      map[string]string
+		Annotations map[string]*Annotation
+		Refs        map[mapTestKey]int
+	}
+	sample := Nested{
+		Labels: map[string]string{
+			"tier": "backend",
+			"app":  "flatstructs",
+		},
+		Annotations: map[string]*Annotation{
+			"owner": {"corpix"},
+		},
+		Refs: map[mapTestKey]int{
+			{"default", "a"}: 1,
+		},
+	}
+	builder := newMapBuilder()
+
+	mapping, err := builder.Map(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result := Nested{
+		Labels: map[string]string{"env": "test"},
+	}
+	err = builder.Unmarshal(mapping, &result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	sample.Labels["env"] = "test"
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(mapping),
+	)
+}
@@ -0,0 +1,92 @@
+package flatstructs
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameMapper converts the Go field name into the key part,
+// it is used for the fields which have no name in the tag.
+type NameMapper func(name string) string
+
+// SnakeCase converts the field name into snake_case(HTTPPort is http_port).
+func SnakeCase(name string) string {
+	return joinWords(splitWords(name), "_", strings.ToLower)
+}
+
+// KebabCase converts the field name into kebab-case(HTTPPort is http-port).
+func KebabCase(name string) string {
+	return joinWords(splitWords(name), "-", strings.ToLower)
+}
+
+// ScreamingSnakeCase converts the field name into SCREAMING_SNAKE_CASE(HTTPPort is HTTP_PORT).
+func ScreamingSnakeCase(name string) string {
+	return joinWords(splitWords(name), "_", strings.ToUpper)
+}
+
+// LowerCamelCase converts the field name into lowerCamelCase(HTTPPort is httpPort),
+// acronyms which are not at the beginning of the name are kept as is(UserID is userID).
+func LowerCamelCase(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	words[0] = strings.ToLower(words[0])
+
+	return strings.Join(words, "")
+}
+
+// splitWords splits the camel case name into words,
+// sequence of upper case letters is treated as an acronym
+// which ends before the upper case letter followed by the lower case one(HTTP, Port),
+// single s after the acronym which ends the name or is followed by the upper case letter
+// is a plural of the acronym(UserIDs is User, IDs).
+// Digits are kept with the preceding word, underscores separate words.
+func splitWords(name string) []string {
+	var (
+		runes = []rune(name)
+		words = []string{}
+		start = 0
+	)
+
+	for k := 0; k < len(runes); k++ {
+		if runes[k] == '_' {
+			if k > start {
+				words = append(words, string(runes[start:k]))
+			}
+			start = k + 1
+			continue
+		}
+		if k == start || !unicode.IsUpper(runes[k]) {
+			continue
+		}
+
+		prev := runes[k-1]
+		next := k+1 < len(runes) && unicode.IsLower(runes[k+1]) && !isAcronymPlural(runes, k+1)
+		if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+			words = append(words, string(runes[start:k]))
+			start = k
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// isAcronymPlural reports whether the rune at k is a single s
+// which ends the acronym, see splitWords().
+func isAcronymPlural(runes []rune, k int) bool {
+	if runes[k] != 's' {
+		return false
+	}
+	return k+1 == len(runes) || unicode.IsUpper(runes[k+1]) || runes[k+1] == '_'
+}
+
+func joinWords(words []string, delimiter string, convert func(string) string) string {
+	for k := range words {
+		words[k] = convert(words[k])
+	}
+	return strings.Join(words, delimiter)
+}
@@ -0,0 +1,65 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameMappers(t *testing.T) {
+	samples := []struct {
+		name       string
+		snake      string
+		kebab      string
+		screaming  string
+		lowerCamel string
+	}{
+		{"UserAgent", "user_agent", "user-agent", "USER_AGENT", "userAgent"},
+		{"HTTPPort", "http_port", "http-port", "HTTP_PORT", "httpPort"},
+		{"UserID", "user_id", "user-id", "USER_ID", "userID"},
+		{"ID", "id", "id", "ID", "id"},
+		{"IDs", "ids", "ids", "IDS", "ids"},
+		{"UserIDs", "user_ids", "user-ids", "USER_IDS", "userIDs"},
+		{"URLs", "urls", "urls", "URLS", "urls"},
+		{"URLsByHost", "urls_by_host", "urls-by-host", "URLS_BY_HOST", "urlsByHost"},
+		{"Base64Value", "base64_value", "base64-value", "BASE64_VALUE", "base64Value"},
+		{"Some_Field", "some_field", "some-field", "SOME_FIELD", "someField"},
+		{"X", "x", "x", "X", "x"},
+	}
+
+	for _, sample := range samples {
+		assert.Equal(t, sample.snake, SnakeCase(sample.name), spew.Sdump(sample))
+		assert.Equal(t, sample.kebab, KebabCase(sample.name), spew.Sdump(sample))
+		assert.Equal(t, sample.screaming, ScreamingSnakeCase(sample.name), spew.Sdump(sample))
+		assert.Equal(t, sample.lowerCamel, LowerCamelCase(sample.name), spew.Sdump(sample))
+	}
+}
+
+func TestBuilderKeysNameMapper(t *testing.T) {
+	type Request struct {
+		UserAgent string
+		Referer   string `key:"referrer"`
+	}
+	type Nested struct {
+		HTTPPort int
+		Request  Request
+	}
+	sample := Nested{}
+
+	builder := NewBuilder("key", ".")
+	builder.NameMapper = SnakeCase
+
+	keys, err := builder.Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"http_port", "request.user_agent", "request.referrer"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
@@ -0,0 +1,96 @@
+package flatstructs
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Path is a sequence of key parts, unlike the keys
+// it could hold key parts which contain the key delimiter.
+type Path []string
+
+// String joins the key parts with the delimiter.
+func (p Path) String(delimiter string) string {
+	return strings.Join(p, delimiter)
+}
+
+// Parent returns the path without the last key part,
+// parent of the empty path is the empty path.
+func (p Path) Parent() Path {
+	if len(p) == 0 {
+		return p
+	}
+	return p[: len(p)-1 : len(p)-1]
+}
+
+// HasPrefix reports whether the path starts with the key parts of the prefix.
+func (p Path) HasPrefix(prefix Path) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+	for k := range prefix {
+		if p[k] != prefix[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// join returns a new path with the parts appended,
+// it never shares the memory with p.
+func (p Path) join(parts ...string) Path {
+	path := make(Path, len(p), len(p)+len(parts))
+	copy(path, p)
+	return append(path, parts...)
+}
+
+// Paths creates a flat slice of paths from a nested structure exported fields
+// in the same order as Keys(), paths hold the key parts which are not joined.
+func (b *Builder) Paths(v interface{}) ([][]string, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.toPaths(v)
+}
+
+// toPaths, see Paths().
+func (b *Builder) toPaths(v interface{}) ([][]string, error) {
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		paths = make([][]string, 0, len(p.fields))
+		root  = rootNode(reflect.ValueOf(v))
+	)
+	root.path = Path{}
+
+	err = b.walkRoot(
+		root,
+		func(n node) error {
+			paths = append(paths, n.path)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+//
+
+// Paths creates a flat slice of paths from a nested structure exported fields.
+// It uses Default Builder.
+func Paths(v interface{}) ([][]string, error) {
+	return Default.Paths(v)
+}
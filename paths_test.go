@@ -0,0 +1,57 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderPaths(t *testing.T) {
+	type Item struct {
+		Name string
+	}
+	type Nested struct {
+		Foo    string `key:"foo"`
+		Items  []Item
+		Labels map[string]string
+	}
+	sample := Nested{
+		Items:  []Item{{"foo"}},
+		Labels: map[string]string{"app.kubernetes.io/name": "web"},
+	}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+	builder.ExpandMaps = true
+
+	paths, err := builder.Paths(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[][]string{
+			{"foo"},
+			{"Items", "0", "Name"},
+			{"Labels", "app.kubernetes.io/name"},
+		},
+		paths,
+		spew.Sdump(sample),
+	)
+}
+
+func TestPath(t *testing.T) {
+	path := Path{"Labels", "app.kubernetes.io/name"}
+
+	assert.Equal(t, "Labels/app.kubernetes.io/name", path.String("/"))
+	assert.Equal(t, Path{"Labels"}, path.Parent())
+	assert.Equal(t, Path{}, path.Parent().Parent())
+	assert.Equal(t, Path{}, Path{}.Parent())
+	assert.True(t, path.HasPrefix(Path{"Labels"}))
+	assert.True(t, path.HasPrefix(nil))
+	assert.False(t, path.HasPrefix(Path{"Label"}))
+	assert.False(t, path.Parent().HasPrefix(path))
+}
@@ -0,0 +1,335 @@
+package flatstructs
+
+import (
+	"reflect"
+	"strings"
+)
+
+// plan is a compiled flat layout of the struct type.
+// It is created once per type by the Builder and reused
+// on every call which needs to walk the values of this type.
+type plan struct {
+	fields []*planField
+	keys   []string
+
+	// err is an error which is found while compiling the plan,
+	// it is cached with the plan and returned on every use.
+	err error
+}
+
+// planField is a leaf of the plan.
+type planField struct {
+	// index is a sequence of field indexes
+	// from the root struct to the leaf.
+	index []int
+
+	// typ is a type of the leaf struct field.
+	typ reflect.Type
+
+	// key is a leaf key relative to the root struct
+	// joined with the Builder.KeySyntax or Builder.KeyDelimiter.
+	key string
+
+	// parts are key parts of the key.
+	parts []string
+
+	// path is a Go path of the leaf relative to the root struct.
+	path string
+
+	// depth is a number of key parts in the key.
+	depth int
+
+	// omitEmpty is true when leaf should be skipped
+	// if it has zero value.
+	omitEmpty bool
+
+	// dynamic is true when layout of the leaf depends on the value,
+	// for example pointers to the types which are already in the chain
+	// of parents, interfaces, slices, maps or types implementing Flattener,
+	// such leafs are walked at runtime.
+	dynamic bool
+}
+
+// field returns the leaf struct field starting from the root struct value.
+// Pointers(including chains of pointers) in the middle of the path
+// are dereferenced, if alloc is true then nil pointers are allocated,
+// otherwise invalid value is returned if leaf is not reachable.
+func (f *planField) field(reflectValue reflect.Value, alloc bool) reflect.Value {
+	last := len(f.index) - 1
+	for k, n := range f.index {
+		reflectValue = reflectValue.Field(n)
+		if k == last {
+			continue
+		}
+		for reflectValue.Kind() == reflect.Ptr {
+			if reflectValue.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				reflectValue.Set(reflect.New(reflectValue.Type().Elem()))
+			}
+			reflectValue = reflectValue.Elem()
+		}
+	}
+
+	return reflectValue
+}
+
+// value returns the leaf value starting from the root struct value,
+// invalid value is returned if leaf is not reachable.
+func (f *planField) value(reflectValue reflect.Value) reflect.Value {
+	reflectValue = f.field(reflectValue, false)
+	if !reflectValue.IsValid() || f.dynamic {
+		return reflectValue
+	}
+	return indirectValue(reflectValue)
+}
+
+// plan returns a cached plan for the struct type
+// compiling it if there is no plan for this type yet.
+func (b *Builder) plan(reflectType reflect.Type) (*plan, error) {
+	b.plansLock.RLock()
+	p, ok := b.plans[reflectType]
+	b.plansLock.RUnlock()
+	if ok {
+		return p, p.err
+	}
+
+	p = &plan{
+		fields: []*planField{},
+		keys:   []string{},
+	}
+	// Leaf types have no leafs of their own,
+	// so they are represented as is wherever they are met.
+	if !b.isLeafType(reflectType) {
+		b.compilePlan(
+			p,
+			reflectType,
+			planScope{
+				index:   []int{},
+				parents: []reflect.Type{reflectType},
+			},
+		)
+	}
+	if b.Strict {
+		p.err = p.checkCollisions()
+	}
+
+	b.plansLock.Lock()
+	if b.plans == nil {
+		b.plans = map[reflect.Type]*plan{}
+	}
+	b.plans[reflectType] = p
+	b.plansLock.Unlock()
+
+	return p, p.err
+}
+
+// checkCollisions returns ErrKeyCollision for the first
+// key which is used by more than one field of the plan.
+func (p *plan) checkCollisions() error {
+	fields := make(map[string]*planField, len(p.fields))
+	for _, field := range p.fields {
+		if other, ok := fields[field.key]; ok {
+			return NewErrKeyCollision(field.key, other.path, field.path)
+		}
+		fields[field.key] = field
+	}
+
+	return nil
+}
+
+// planScope is a state of the plan compilation
+// for the struct which is nested into the root struct.
+type planScope struct {
+	prefix    string
+	parts     []string
+	path      string
+	depth     int
+	index     []int
+	parents   []reflect.Type
+	omitEmpty bool
+}
+
+// compilePlan, see plan().
+func (b *Builder) compilePlan(p *plan, reflectType reflect.Type, scope planScope) {
+	var (
+		fieldType reflect.Type
+		tag       fieldTag
+		nested    planScope
+		leaf      bool
+		length    int
+	)
+
+	for _, field := range b.structFields(reflectType) {
+		tag = field.tag
+		nested = planScope{
+			prefix:    b.joinKey(scope.prefix, tag.name),
+			parts:     append(scope.parts[:len(scope.parts):len(scope.parts)], tag.name),
+			path:      joinFieldPath(scope.path, field.path),
+			depth:     scope.depth + 1,
+			index:     append(scope.index[:len(scope.index):len(scope.index)], field.Index...),
+			parents:   scope.parents,
+			omitEmpty: scope.omitEmpty || tag.omitEmpty,
+		}
+		fieldType = indirectType(field.Type)
+		leaf = tag.leaf || (b.isLeafType(field.Type) && !isFlattenerType(field.Type))
+
+		if !leaf && b.isDynamicType(field.Type, scope.parents) {
+			p.addField(&planField{
+				index:     nested.index,
+				typ:       field.Type,
+				key:       nested.prefix,
+				parts:     nested.parts,
+				path:      nested.path,
+				depth:     nested.depth,
+				omitEmpty: nested.omitEmpty,
+				dynamic:   true,
+			})
+			continue
+		}
+
+		if fieldType.Kind() == reflect.Struct && !leaf {
+			if tag.inline {
+				nested.prefix = scope.prefix
+				nested.parts = scope.parts
+				nested.depth = scope.depth
+			}
+			nested.parents = append(scope.parents[:len(scope.parents):len(scope.parents)], fieldType)
+
+			length = len(p.fields)
+			b.compilePlan(p, fieldType, nested)
+			if len(p.fields) > length {
+				continue
+			}
+		}
+
+		p.addField(&planField{
+			index:     nested.index,
+			typ:       field.Type,
+			key:       nested.prefix,
+			parts:     nested.parts,
+			path:      nested.path,
+			depth:     nested.depth,
+			omitEmpty: nested.omitEmpty,
+		})
+	}
+}
+
+// isDynamicType reports whether the layout of the field type
+// could be known only at runtime, see planField.dynamic.
+func (b *Builder) isDynamicType(reflectType reflect.Type, parents []reflect.Type) bool {
+	if isFlattenerType(reflectType) {
+		return true
+	}
+
+	if reflectType.Kind() == reflect.Ptr {
+		reflectType = indirectType(reflectType)
+		if reflectType.Kind() == reflect.Struct && isTypeIn(reflectType, parents) {
+			return true
+		}
+	}
+
+	switch reflectType.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Array:
+		return b.ExpandSlices && !isBytesType(reflectType)
+	case reflect.Map:
+		return b.ExpandMaps && isExpandableMapType(reflectType)
+	default:
+		return false
+	}
+}
+
+// isNestedType reports whether the value of the type
+// has leafs of its own, so it should be walked instead
+// of being represented as a single leaf.
+// It is used for the values of the interfaces
+// where the type is known only at runtime.
+func (b *Builder) isNestedType(reflectType reflect.Type) (bool, error) {
+	if isFlattenerType(reflectType) {
+		return true, nil
+	}
+	if b.isLeafType(reflectType) {
+		return false, nil
+	}
+	reflectType = indirectType(reflectType)
+
+	switch reflectType.Kind() {
+	case reflect.Struct:
+		p, err := b.plan(reflectType)
+		if err != nil {
+			return false, err
+		}
+		return len(p.fields) > 0, nil
+	case reflect.Slice, reflect.Array:
+		return b.ExpandSlices && !isBytesType(reflectType), nil
+	case reflect.Map:
+		return b.ExpandMaps && isExpandableMapType(reflectType), nil
+	default:
+		return false, nil
+	}
+}
+
+func (p *plan) addField(field *planField) {
+	p.fields = append(p.fields, field)
+	p.keys = append(p.keys, field.key)
+}
+
+// joinKey joins the key part with the prefix key using Builder.KeySyntax
+// or Builder.KeyDelimiter if there is no syntax.
+func (b *Builder) joinKey(prefix, key string) string {
+	if b.KeySyntax != nil {
+		return b.KeySyntax.JoinKey(prefix, key)
+	}
+	if prefix == "" {
+		return key
+	}
+	return prefix + b.KeyDelimiter + key
+}
+
+// appendKey appends the key which consists of the key parts to the prefix key.
+// Only the keys which are joined by the Builder.KeyDelimiter
+// could be appended without joining the key parts one by one.
+func (b *Builder) appendKey(prefix, key string, parts []string) string {
+	if prefix == "" {
+		return key
+	}
+	if b.KeySyntax == nil {
+		return prefix + b.KeyDelimiter + key
+	}
+
+	for _, part := range parts {
+		prefix = b.KeySyntax.JoinKey(prefix, part)
+	}
+	return prefix
+}
+
+// splitKey splits the key into the key parts using Builder.KeySyntax
+// or Builder.KeyDelimiter if there is no syntax.
+// Keys are not splitted if there is no syntax and Builder.KeyDelimiter is empty.
+func (b *Builder) splitKey(key string) ([]string, error) {
+	if b.KeySyntax != nil {
+		return b.KeySyntax.SplitKey(key)
+	}
+	if b.KeyDelimiter == "" {
+		return []string{key}, nil
+	}
+	return strings.Split(key, b.KeyDelimiter), nil
+}
+
+func isTypeIn(reflectType reflect.Type, types []reflect.Type) bool {
+	for _, t := range types {
+		if t == reflectType {
+			return true
+		}
+	}
+	return false
+}
+
+// isBytesType reports whether reflectType is a slice or array of bytes,
+// such types are always represented as a single leaf.
+func isBytesType(reflectType reflect.Type) bool {
+	return reflectType.Elem().Kind() == reflect.Uint8
+}
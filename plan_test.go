@@ -0,0 +1,100 @@
+package flatstructs
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderPlanCached(t *testing.T) {
+	type Flat struct {
+		Foo string
+	}
+	builder := NewBuilder("key", "")
+	reflectType := reflect.TypeOf(Flat{})
+
+	p, err := builder.plan(reflectType)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cached, err := builder.plan(reflectType)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if p != cached {
+		t.Error("Plan should be compiled once per type")
+	}
+}
+
+func TestBuilderPlanConcurrent(t *testing.T) {
+	type Flat struct {
+		Baz string
+	}
+	type Nested struct {
+		Foo string
+		Bar *Flat
+	}
+	var (
+		builder = NewBuilder("key", ".")
+		wg      = &sync.WaitGroup{}
+		results = make([][]string, 16)
+	)
+
+	for n := range results {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			results[n], _ = builder.Keys(&Nested{"foo", &Flat{"baz"}})
+		}(n)
+	}
+	wg.Wait()
+
+	for _, keys := range results {
+		assert.Equal(
+			t,
+			[]string{"Foo", "Bar.Baz"},
+			keys,
+		)
+	}
+}
+
+func TestBuilderKeysRecursive(t *testing.T) {
+	type Node struct {
+		Value int
+		Next  *Node
+	}
+	sample := Node{1, &Node{2, &Node{3, nil}}}
+
+	keys, err := NewBuilder("key", ".").Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"Value", "Next.Value", "Next.Next.Value"},
+		keys,
+		spew.Sdump(sample),
+	)
+
+	values, err := NewBuilder("key", ".").Values(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]interface{}{1, 2, 3},
+		values,
+		spew.Sdump(sample),
+	)
+}
@@ -0,0 +1,42 @@
+package flatstructs
+
+// Pointers creates a flat slice of pointers to the leaf fields
+// of a nested structure v points to, so the leafs could be
+// assigned by the database/sql Rows.Scan().
+// Nil pointers to the nested structures are allocated,
+// pointers are in the KeysOf() order, so it is
+// the same order Keys() has when there are no nil pointers.
+// Leafs which are walked at runtime(slices, maps and so on)
+// are pointed as a single leaf like in the Schema.
+func (b *Builder) Pointers(v interface{}) ([]interface{}, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	pointers := make([]interface{}, len(p.fields))
+	for k, field := range p.fields {
+		pointers[k] = field.field(reflectValue, true).Addr().Interface()
+	}
+
+	return pointers, nil
+}
+
+//
+
+// Pointers creates a flat slice of pointers to the leaf fields
+// of a nested structure v points to.
+// It uses Default Builder.
+func Pointers(v interface{}) ([]interface{}, error) {
+	return Default.Pointers(v)
+}
@@ -0,0 +1,51 @@
+package flatstructs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderPointers(t *testing.T) {
+	type Flat struct {
+		Baz  string
+		Jazz *int
+	}
+	type Nested struct {
+		Foo string
+		Bar *Flat
+	}
+	var (
+		sample = Nested{}
+		jazz   = 1
+	)
+
+	builder := NewBuilder("key", ".")
+
+	pointers, err := builder.Pointers(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	keys, err := builder.KeysOf(reflect.TypeOf(sample))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, []string{"Foo", "Bar.Baz", "Bar.Jazz"}, keys, spew.Sdump(sample))
+
+	for k, value := range []interface{}{"foo", "baz", &jazz} {
+		reflectValue := reflect.ValueOf(pointers[k]).Elem()
+		reflectValue.Set(reflect.ValueOf(value))
+	}
+
+	assert.Equal(
+		t,
+		Nested{"foo", &Flat{"baz", &jazz}},
+		sample,
+		spew.Sdump(sample),
+	)
+}
@@ -0,0 +1,169 @@
+package flatstructs
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+)
+
+// ScanStrictness controls which mismatches between the
+// columns and the leafs are reported by ScanRows().
+type ScanStrictness int
+
+const (
+	// ScanRejectUnmappedColumns reports columns which do
+	// not match any leaf as ErrUnmappedColumns,
+	// otherwise their values are discarded.
+	ScanRejectUnmappedColumns ScanStrictness = 1 << iota
+
+	// ScanRejectUnmappedFields reports leafs which do
+	// not match any column as ErrUnmappedFields,
+	// otherwise they are left untouched.
+	ScanRejectUnmappedFields
+
+	// ScanRejectUnmapped reports both unmapped columns and leafs.
+	ScanRejectUnmapped = ScanRejectUnmappedColumns | ScanRejectUnmappedFields
+)
+
+// ScanRows scans every row into a new struct matching
+// columns to the flat keys of the struct(see KeysOf()) and appends
+// structs to the slice dst points to, it could be a slice
+// of structs or a slice of pointers to structs.
+// Columns are matched case-insensitively unless
+// Builder.ScanCaseSensitive is set, exact match is preferred.
+// Nil pointers to the nested structures are allocated only
+// if there is a column for at least one of their leafs.
+// Mismatches are reported according to the Builder.ScanStrictness.
+// Rows are not closed, Rows.Err() is reported after the last row.
+func (b *Builder) ScanRows(rows *sql.Rows, dst interface{}) error {
+	err := checkValue(dst)
+	if err != nil {
+		return err
+	}
+
+	var (
+		slice    = reflect.ValueOf(dst).Elem()
+		elemType reflect.Type
+	)
+	if slice.Kind() != reflect.Slice {
+		return NewErrInvalidKind(reflect.Slice, slice.Kind())
+	}
+	elemType = slice.Type().Elem()
+	structType := indirectType(elemType)
+	if structType.Kind() != reflect.Struct {
+		return NewErrInvalidKind(reflect.Struct, structType.Kind())
+	}
+	if elemType.Kind() == reflect.Ptr && elemType.Elem() != structType {
+		return NewErrInvalidType(reflect.PtrTo(structType), elemType)
+	}
+
+	p, err := b.plan(structType)
+	if err != nil {
+		return err
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fields, err := b.columnFields(p, columns)
+	if err != nil {
+		return err
+	}
+
+	var (
+		pointers = make([]interface{}, len(columns))
+		discard  interface{}
+		item     reflect.Value
+	)
+	for rows.Next() {
+		item = reflect.New(structType)
+		for k, field := range fields {
+			if field == nil {
+				pointers[k] = &discard
+				continue
+			}
+			pointers[k] = field.field(item.Elem(), true).Addr().Interface()
+		}
+
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return err
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+// columnFields matches columns to the plan fields,
+// nil is returned for the columns which do not match any field.
+// Unmapped columns and fields are reported according
+// to the Builder.ScanStrictness, see ScanRows().
+func (b *Builder) columnFields(p *plan, columns []string) ([]*planField, error) {
+	var (
+		fields   = make([]*planField, len(columns))
+		mapped   = make(map[*planField]bool, len(p.fields))
+		unmapped = []string{}
+	)
+
+	for k, column := range columns {
+		fields[k] = b.columnField(p, column)
+		if fields[k] == nil {
+			unmapped = append(unmapped, column)
+			continue
+		}
+		mapped[fields[k]] = true
+	}
+	if len(unmapped) > 0 && b.ScanStrictness&ScanRejectUnmappedColumns != 0 {
+		return nil, NewErrUnmappedColumns(unmapped)
+	}
+
+	if b.ScanStrictness&ScanRejectUnmappedFields != 0 {
+		unmapped = []string{}
+		for _, field := range p.fields {
+			if !mapped[field] {
+				unmapped = append(unmapped, field.key)
+			}
+		}
+		if len(unmapped) > 0 {
+			return nil, NewErrUnmappedFields(unmapped)
+		}
+	}
+
+	return fields, nil
+}
+
+// columnField returns the plan field which key matches the column,
+// see ScanRows().
+func (b *Builder) columnField(p *plan, column string) *planField {
+	for _, field := range p.fields {
+		if field.key == column {
+			return field
+		}
+	}
+	if b.ScanCaseSensitive {
+		return nil
+	}
+
+	for _, field := range p.fields {
+		if strings.EqualFold(field.key, column) {
+			return field
+		}
+	}
+	return nil
+}
+
+//
+
+// ScanRows scans every row into a new struct and appends
+// structs to the slice dst points to.
+// It uses Default Builder.
+func ScanRows(rows *sql.Rows, dst interface{}) error {
+	return Default.ScanRows(rows, dst)
+}
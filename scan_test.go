@@ -0,0 +1,212 @@
+package flatstructs
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+// scanTestResults are results of the queries
+// which are returned by the scanTestDriver.
+var scanTestResults = map[string]*scanTestRows{
+	"users": {
+		columns: []string{"id", "name", "address.city", "ADDRESS.ZIP"},
+		values: [][]driver.Value{
+			{int64(1), "foo", "Berlin", ""},
+			{int64(2), "bar", nil, "10115"},
+		},
+	},
+	"unmapped": {
+		columns: []string{"id", "email"},
+		values:  [][]driver.Value{{int64(1), "foo@example.com"}},
+	},
+}
+
+func init() {
+	sql.Register("flatstructs", scanTestDriver{})
+}
+
+type scanTestDriver struct{}
+
+func (scanTestDriver) Open(name string) (driver.Conn, error) {
+	return scanTestConn{}, nil
+}
+
+type scanTestConn struct{}
+
+func (scanTestConn) Prepare(query string) (driver.Stmt, error) {
+	return scanTestStmt{query}, nil
+}
+
+func (scanTestConn) Close() error {
+	return nil
+}
+
+func (scanTestConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type scanTestStmt struct {
+	query string
+}
+
+func (scanTestStmt) Close() error {
+	return nil
+}
+
+func (scanTestStmt) NumInput() int {
+	return 0
+}
+
+func (scanTestStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s scanTestStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, ok := scanTestResults[s.query]
+	if !ok {
+		return nil, errors.New("unknown query " + s.query)
+	}
+	return &scanTestRows{columns: rows.columns, values: rows.values}, nil
+}
+
+type scanTestRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *scanTestRows) Columns() []string {
+	return r.columns
+}
+
+func (r *scanTestRows) Close() error {
+	return nil
+}
+
+func (r *scanTestRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func scanTestQuery(t *testing.T, query string) (*sql.DB, *sql.Rows) {
+	db, err := sql.Open("flatstructs", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, rows
+}
+
+type scanTestAddress struct {
+	City *string `key:"city"`
+	Zip  string  `key:"zip"`
+}
+
+type scanTestUser struct {
+	ID      int64            `key:"id"`
+	Name    string           `key:"name"`
+	Address *scanTestAddress `key:"address"`
+}
+
+func TestBuilderScanRows(t *testing.T) {
+	db, rows := scanTestQuery(t, "users")
+	defer db.Close()
+	defer rows.Close()
+
+	var (
+		users = []scanTestUser{}
+		city  = "Berlin"
+	)
+
+	err := NewBuilder("key", ".").ScanRows(rows, &users)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]scanTestUser{
+			{1, "foo", &scanTestAddress{City: &city}},
+			{2, "bar", &scanTestAddress{Zip: "10115"}},
+		},
+		users,
+		spew.Sdump(users),
+	)
+}
+
+func TestBuilderScanRowsPtr(t *testing.T) {
+	db, rows := scanTestQuery(t, "unmapped")
+	defer db.Close()
+	defer rows.Close()
+
+	users := []*scanTestUser{}
+
+	err := NewBuilder("key", ".").ScanRows(rows, &users)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]*scanTestUser{{ID: 1}},
+		users,
+		spew.Sdump(users),
+	)
+}
+
+func TestBuilderScanRowsStrictness(t *testing.T) {
+	for _, test := range []struct {
+		query      string
+		strictness ScanStrictness
+		err        error
+	}{
+		{"unmapped", ScanRejectUnmappedColumns, &ErrUnmappedColumns{[]string{"email"}}},
+		{"unmapped", ScanRejectUnmappedFields, &ErrUnmappedFields{[]string{"name", "address.city", "address.zip"}}},
+		{"users", ScanRejectUnmapped, nil},
+	} {
+		db, rows := scanTestQuery(t, test.query)
+
+		builder := NewBuilder("key", ".")
+		builder.ScanStrictness = test.strictness
+
+		users := []scanTestUser{}
+		err := builder.ScanRows(rows, &users)
+		assert.Equal(t, test.err, err, spew.Sdump(test))
+
+		rows.Close()
+		db.Close()
+	}
+}
+
+func TestBuilderScanRowsCaseSensitive(t *testing.T) {
+	db, rows := scanTestQuery(t, "users")
+	defer db.Close()
+	defer rows.Close()
+
+	builder := NewBuilder("key", ".")
+	builder.ScanStrictness = ScanRejectUnmappedColumns
+	builder.ScanCaseSensitive = true
+
+	users := []scanTestUser{}
+	err := builder.ScanRows(rows, &users)
+	assert.Equal(
+		t,
+		&ErrUnmappedColumns{[]string{"ADDRESS.ZIP"}},
+		err,
+		spew.Sdump(users),
+	)
+}
@@ -0,0 +1,98 @@
+package flatstructs
+
+import (
+	"reflect"
+)
+
+// Schema is a flat layout of the struct type.
+// Unlike Keys() it is derived from the type alone,
+// so nested structures behind nil pointers are
+// present in the Schema and keys are stable
+// across the values of the same type.
+// Recursive references to the types which are already
+// in the chain, interfaces and Flattener values
+// are represented as a single leaf.
+type Schema struct {
+	Type reflect.Type
+	Keys []string
+	plan *plan
+}
+
+// Values creates a flat slice of values in the Schema.Keys order.
+// Leafs which are not reachable because of nil pointers
+// are reported as nil.
+func (s *Schema) Values(v interface{}) ([]interface{}, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	reflectValue := indirectValue(reflect.ValueOf(v))
+	if !reflectValue.IsValid() {
+		return nil, NewErrInvalid(v)
+	}
+
+	if reflectValue.Type() != s.Type {
+		return nil, NewErrInvalidType(s.Type, reflectValue.Type())
+	}
+
+	var (
+		values     = make([]interface{}, len(s.plan.fields))
+		fieldValue reflect.Value
+	)
+	for k, field := range s.plan.fields {
+		fieldValue = field.value(reflectValue)
+		if fieldValue.IsValid() {
+			values[k] = fieldValue.Interface()
+		}
+	}
+
+	return values, nil
+}
+
+// Schema creates a flat layout for the struct type
+// or a pointer to the struct type.
+func (b *Builder) Schema(reflectType reflect.Type) (*Schema, error) {
+	if reflectType == nil {
+		return nil, NewErrInvalid(nil)
+	}
+	if reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+	if reflectType.Kind() != reflect.Struct {
+		return nil, NewErrInvalidKind(
+			reflect.Struct,
+			reflectType.Kind(),
+		)
+	}
+
+	p, err := b.plan(reflectType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{
+		Type: reflectType,
+		Keys: append([]string{}, p.keys...),
+		plan: p,
+	}, nil
+}
+
+// KeysOf creates a flat slice of keys from a struct type.
+// Unlike Keys() it does not skip nested structures behind nil pointers.
+func (b *Builder) KeysOf(reflectType reflect.Type) ([]string, error) {
+	schema, err := b.Schema(reflectType)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Keys, nil
+}
+
+//
+
+// KeysOf creates a flat slice of keys from a struct type.
+// It uses Default Builder.
+func KeysOf(reflectType reflect.Type) ([]string, error) {
+	return Default.KeysOf(reflectType)
+}
@@ -0,0 +1,156 @@
+package flatstructs
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderKeysOfNotStruct(t *testing.T) {
+	keys, err := KeysOf(reflect.TypeOf([]string{}))
+	if err == nil {
+		t.Error("Slice type as argument should be reported as ErrInvalidKind")
+		return
+	}
+
+	if _, ok := err.(*ErrInvalidKind); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrInvalidKind, got '%T'",
+			err,
+		)
+	}
+
+	assert.Equal(
+		t,
+		([]string)(nil),
+		keys,
+	)
+}
+
+func TestBuilderKeysOfNil(t *testing.T) {
+	keys, err := KeysOf(nil)
+	if err == nil {
+		t.Error("Nil type as argument should be reported as ErrInvalid")
+		return
+	}
+
+	if _, ok := err.(*ErrInvalid); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrInvalid, got '%T'",
+			err,
+		)
+	}
+
+	assert.Equal(
+		t,
+		([]string)(nil),
+		keys,
+	)
+}
+
+func TestBuilderKeysOfNestedNil(t *testing.T) {
+	type Flat struct {
+		Baz string `key:"baz"`
+	}
+	type Nested struct {
+		Foo string
+		Bar *Flat
+		Baz string
+		Daz *Flat
+	}
+	sample := Nested{"foo", nil, "baz", &Flat{"daz"}}
+
+	keys, err := KeysOf(reflect.TypeOf(&sample))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"Foo", "Barbaz", "Baz", "Dazbaz"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysOfRecursive(t *testing.T) {
+	type Node struct {
+		Value int
+		Next  *Node
+	}
+
+	keys, err := NewBuilder("key", ".").KeysOf(reflect.TypeOf(Node{}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"Value", "Next"},
+		keys,
+	)
+}
+
+func TestSchemaValuesNestedNil(t *testing.T) {
+	type Flat struct {
+		Baz string
+	}
+	type Nested struct {
+		Foo string
+		Bar *Flat
+		Baz string
+		Daz *Flat
+	}
+	sample := Nested{"foo", nil, "baz", &Flat{"daz"}}
+
+	schema, err := Default.Schema(reflect.TypeOf(sample))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	values, err := schema.Values(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]interface{}{"foo", nil, "baz", "daz"},
+		values,
+		spew.Sdump(sample),
+	)
+}
+
+func TestSchemaValuesInvalidType(t *testing.T) {
+	type Flat struct {
+		Foo string
+	}
+	type Other struct {
+		Foo string
+	}
+
+	schema, err := Default.Schema(reflect.TypeOf(Flat{}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = schema.Values(&Other{})
+	if err == nil {
+		t.Error("Value of other type should be reported as ErrInvalidType")
+		return
+	}
+
+	if _, ok := err.(*ErrInvalidType); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrInvalidType, got '%T'",
+			err,
+		)
+	}
+}
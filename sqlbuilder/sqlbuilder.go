@@ -0,0 +1,243 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/corpix/flatstructs"
+)
+
+// Placeholder returns a placeholder for the argument
+// with the number n(starting from 1) which is assigned to the column.
+type Placeholder func(n int, column string) string
+
+var (
+	// Question is a placeholder style of MySQL and SQLite, ?.
+	Question Placeholder = func(n int, column string) string { return "?" }
+
+	// Dollar is a placeholder style of PostgreSQL, $1.
+	Dollar Placeholder = func(n int, column string) string { return "$" + strconv.Itoa(n) }
+
+	// Named is a named placeholder style, :column.
+	// Columns should be valid placeholder names,
+	// so use the key delimiter like _ with it.
+	Named Placeholder = func(n int, column string) string { return ":" + column }
+)
+
+// Quote returns a quoted column or table name.
+type Quote func(identifier string) string
+
+var (
+	// DoubleQuotes quotes identifiers like PostgreSQL and SQLite do, "address.city".
+	DoubleQuotes Quote = func(identifier string) string {
+		return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+	}
+
+	// Backticks quotes identifiers like MySQL does, `address.city`.
+	Backticks Quote = func(identifier string) string {
+		return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
+	}
+)
+
+// Builder builds SQL statements for the nested records
+// using flat keys as column names.
+// Columns are derived from the record type(see flatstructs.Builder.Schema()),
+// so they are stable across the records of the same type,
+// leafs which are not reachable because of nil pointers are NULL.
+type Builder struct {
+	Flat        *flatstructs.Builder
+	Placeholder Placeholder
+
+	// Quote quotes column and table names if it is set.
+	Quote Quote
+}
+
+// Columns returns a column list of the record.
+func (b *Builder) Columns(v interface{}) ([]string, error) {
+	schema, err := b.Flat.Schema(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.Keys, nil
+}
+
+// Placeholders returns placeholders for the columns
+// starting from the argument number n.
+func (b *Builder) Placeholders(n int, columns []string) []string {
+	placeholders := make([]string, len(columns))
+	for k, column := range columns {
+		placeholders[k] = b.Placeholder(n+k, column)
+	}
+
+	return placeholders
+}
+
+// Insert returns INSERT statement for the record v points to
+// with the arguments in the column order.
+func (b *Builder) Insert(table string, v interface{}) (string, []interface{}, error) {
+	columns, args, err := b.columns(v)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := "INSERT INTO " + b.quote(table) +
+		" (" + strings.Join(b.quoteAll(columns), ", ") + ")" +
+		" VALUES (" + strings.Join(b.Placeholders(1, columns), ", ") + ")"
+
+	return query, args, nil
+}
+
+// UpdateNonZero returns UPDATE statement which sets only
+// the columns with non-zero values of the record v points to.
+// Record is looked up by the keys, their values
+// are taken from the record too, keys are never set.
+// Statement is empty if there is nothing to set.
+func (b *Builder) UpdateNonZero(table string, v interface{}, keys ...string) (string, []interface{}, error) {
+	columns, args, err := b.columns(v)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return b.update(
+		table,
+		columns,
+		args,
+		args,
+		func(k int) bool { return !isZero(args[k]) },
+		keys,
+	)
+}
+
+// UpdateChanged returns UPDATE statement which sets only
+// the columns with values which differ between the previous
+// and the next records of the same type.
+// Record is looked up by the keys, their values
+// are taken from the previous record, keys are never set.
+// Statement is empty if there is nothing to set.
+func (b *Builder) UpdateChanged(table string, prev, next interface{}, keys ...string) (string, []interface{}, error) {
+	if reflect.TypeOf(prev) != reflect.TypeOf(next) {
+		return "", nil, flatstructs.NewErrInvalidType(reflect.TypeOf(next), reflect.TypeOf(prev))
+	}
+
+	columns, args, err := b.columns(next)
+	if err != nil {
+		return "", nil, err
+	}
+	_, prevArgs, err := b.columns(prev)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return b.update(
+		table,
+		columns,
+		args,
+		prevArgs,
+		func(k int) bool { return !reflect.DeepEqual(prevArgs[k], args[k]) },
+		keys,
+	)
+}
+
+// update returns UPDATE statement which sets the columns
+// for which set returns true except the keys,
+// values of the keys are taken from where, see UpdateNonZero().
+func (b *Builder) update(table string, columns []string, args, where []interface{}, set func(k int) bool, keys []string) (string, []interface{}, error) {
+	var (
+		assignments = []string{}
+		conditions  = make([]string, len(keys))
+		result      = []interface{}{}
+		values      = make(map[string]interface{}, len(columns))
+		isKey       = make(map[string]bool, len(keys))
+	)
+
+	for k, column := range columns {
+		values[column] = where[k]
+	}
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			return "", nil, flatstructs.NewErrKeyNotFound(key)
+		}
+		isKey[key] = true
+	}
+
+	for k, column := range columns {
+		if isKey[column] || !set(k) {
+			continue
+		}
+		result = append(result, args[k])
+		assignments = append(
+			assignments,
+			b.quote(column)+" = "+b.Placeholder(len(result), column),
+		)
+	}
+	if len(assignments) == 0 {
+		return "", nil, nil
+	}
+
+	for k, key := range keys {
+		result = append(result, values[key])
+		conditions[k] = b.quote(key) + " = " + b.Placeholder(len(result), key)
+	}
+
+	query := "UPDATE " + b.quote(table) + " SET " + strings.Join(assignments, ", ")
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return query, result, nil
+}
+
+// columns returns the columns and the values of the record v points to.
+func (b *Builder) columns(v interface{}) ([]string, []interface{}, error) {
+	if reflect.ValueOf(v).Kind() != reflect.Ptr {
+		return nil, nil, flatstructs.NewErrPtrRequired(v)
+	}
+
+	schema, err := b.Flat.Schema(reflect.TypeOf(v))
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := schema.Values(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schema.Keys, values, nil
+}
+
+func (b *Builder) quote(identifier string) string {
+	if b.Quote == nil {
+		return identifier
+	}
+	return b.Quote(identifier)
+}
+
+func (b *Builder) quoteAll(identifiers []string) []string {
+	quoted := make([]string, len(identifiers))
+	for k, identifier := range identifiers {
+		quoted[k] = b.quote(identifier)
+	}
+	return quoted
+}
+
+// isZero reports whether v is nil or a zero value of its type.
+func isZero(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	return reflect.DeepEqual(v, reflect.Zero(reflect.TypeOf(v)).Interface())
+}
+
+//
+
+// New creates new statement builder which uses
+// flat builder to derive columns and placeholder
+// to create placeholders for the arguments.
+func New(flat *flatstructs.Builder, placeholder Placeholder) *Builder {
+	return &Builder{
+		Flat:        flat,
+		Placeholder: placeholder,
+	}
+}
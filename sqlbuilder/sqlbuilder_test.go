@@ -0,0 +1,172 @@
+package sqlbuilder
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/corpix/flatstructs"
+)
+
+type testAddress struct {
+	City string `key:"city"`
+	Zip  string `key:"zip"`
+}
+
+type testUser struct {
+	ID      int          `key:"id"`
+	Name    string       `key:"name"`
+	Address *testAddress `key:"address"`
+}
+
+func TestBuilderInsert(t *testing.T) {
+	sample := testUser{1, "foo", &testAddress{"Berlin", "10115"}}
+
+	for _, test := range []struct {
+		builder *Builder
+		query   string
+	}{
+		{
+			New(flatstructs.NewBuilder("key", "."), Question),
+			"INSERT INTO users (id, name, address.city, address.zip) VALUES (?, ?, ?, ?)",
+		},
+		{
+			&Builder{
+				Flat:        flatstructs.NewBuilder("key", "."),
+				Placeholder: Dollar,
+				Quote:       DoubleQuotes,
+			},
+			`INSERT INTO "users" ("id", "name", "address.city", "address.zip") VALUES ($1, $2, $3, $4)`,
+		},
+		{
+			New(flatstructs.NewBuilder("key", "_"), Named),
+			"INSERT INTO users (id, name, address_city, address_zip) VALUES (:id, :name, :address_city, :address_zip)",
+		},
+	} {
+		query, args, err := test.builder.Insert("users", &sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.Equal(t, test.query, query, spew.Sdump(sample))
+		assert.Equal(
+			t,
+			[]interface{}{1, "foo", "Berlin", "10115"},
+			args,
+			spew.Sdump(sample),
+		)
+	}
+}
+
+func TestBuilderInsertNil(t *testing.T) {
+	sample := testUser{ID: 1}
+
+	query, args, err := New(flatstructs.NewBuilder("key", "."), Question).Insert("users", &sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		"INSERT INTO users (id, name, address.city, address.zip) VALUES (?, ?, ?, ?)",
+		query,
+		spew.Sdump(sample),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{1, "", nil, nil},
+		args,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUpdateNonZero(t *testing.T) {
+	sample := testUser{ID: 1, Address: &testAddress{City: "Berlin"}}
+
+	query, args, err := New(flatstructs.NewBuilder("key", "."), Dollar).UpdateNonZero("users", &sample, "id")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		"UPDATE users SET address.city = $1 WHERE id = $2",
+		query,
+		spew.Sdump(sample),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{"Berlin", 1},
+		args,
+		spew.Sdump(sample),
+	)
+
+	_, _, err = New(flatstructs.NewBuilder("key", "."), Dollar).UpdateNonZero("users", &testUser{}, "typo")
+	assert.Equal(t, flatstructs.NewErrKeyNotFound("typo"), err, spew.Sdump(sample))
+}
+
+func TestBuilderUpdateChanged(t *testing.T) {
+	var (
+		prev = testUser{1, "foo", &testAddress{"Berlin", "10115"}}
+		next = testUser{1, "bar", &testAddress{"Berlin", "10117"}}
+	)
+
+	builder := New(flatstructs.NewBuilder("key", "."), Question)
+
+	query, args, err := builder.UpdateChanged("users", &prev, &next, "id")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		"UPDATE users SET name = ?, address.zip = ? WHERE id = ?",
+		query,
+		spew.Sdump(prev, next),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{"bar", "10117", 1},
+		args,
+		spew.Sdump(prev, next),
+	)
+
+	query, args, err = builder.UpdateChanged("users", &prev, &prev, "id")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(t, "", query, spew.Sdump(prev))
+	assert.Equal(t, ([]interface{})(nil), args, spew.Sdump(prev))
+
+	moved := next
+	moved.ID = 2
+	query, args, err = builder.UpdateChanged("users", &prev, &moved, "id")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Equal(
+		t,
+		"UPDATE users SET name = ?, address.zip = ? WHERE id = ?",
+		query,
+		spew.Sdump(prev, moved),
+	)
+	assert.Equal(
+		t,
+		[]interface{}{"bar", "10117", 1},
+		args,
+		spew.Sdump(prev, moved),
+	)
+
+	_, _, err = builder.UpdateChanged("users", &prev, &next, "email")
+	assert.Equal(t, flatstructs.NewErrKeyNotFound("email"), err, spew.Sdump(prev))
+
+	_, _, err = builder.UpdateChanged("users", &prev, &prev, "email")
+	assert.Equal(t, flatstructs.NewErrKeyNotFound("email"), err, spew.Sdump(prev))
+}
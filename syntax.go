@@ -0,0 +1,253 @@
+package flatstructs
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeySyntax defines how key parts are joined into the key
+// and how the key is parsed back into the key parts.
+// Keys should be concatenative: key of the nested key part
+// is the key of the parent followed by the key part.
+type KeySyntax interface {
+	// JoinKey appends the key part to the prefix key,
+	// prefix is empty for the first key part.
+	JoinKey(prefix, part string) string
+
+	// SplitKey parses the key into the key parts,
+	// malformed key is reported as ErrInvalidKey.
+	SplitKey(key string) ([]string, error)
+}
+
+var (
+	// JSONPointer is a KeySyntax of the RFC 6901 JSON Pointer(/a~1b/c).
+	JSONPointer KeySyntax = JSONPointerSyntax{}
+
+	// Brackets is a KeySyntax of the bracket notation(a["b.c"][0]).
+	Brackets KeySyntax = BracketSyntax{}
+)
+
+//
+
+// DelimitedSyntax joins the key parts with the Delimiter,
+// delimiters and backslashes in the key parts are escaped
+// with a backslash(a\.b.c), so keys could be parsed back.
+type DelimitedSyntax struct {
+	Delimiter string
+}
+
+func (s DelimitedSyntax) JoinKey(prefix, part string) string {
+	part = strings.Replace(part, `\`, `\\`, -1)
+	if s.Delimiter != "" {
+		part = strings.Replace(part, s.Delimiter, `\`+s.Delimiter, -1)
+	}
+
+	if prefix == "" {
+		return part
+	}
+	return prefix + s.Delimiter + part
+}
+
+func (s DelimitedSyntax) SplitKey(key string) ([]string, error) {
+	var (
+		parts = []string{}
+		part  = []byte{}
+	)
+
+	for k := 0; k < len(key); {
+		switch {
+		case key[k] == '\\':
+			switch {
+			case strings.HasPrefix(key[k+1:], `\`):
+				part = append(part, '\\')
+				k += 2
+			case s.Delimiter != "" && strings.HasPrefix(key[k+1:], s.Delimiter):
+				part = append(part, s.Delimiter...)
+				k += 1 + len(s.Delimiter)
+			default:
+				return nil, NewErrInvalidKey(key, k)
+			}
+		case s.Delimiter != "" && strings.HasPrefix(key[k:], s.Delimiter):
+			parts = append(parts, string(part))
+			part = part[:0]
+			k += len(s.Delimiter)
+		default:
+			part = append(part, key[k])
+			k++
+		}
+	}
+
+	return append(parts, string(part)), nil
+}
+
+//
+
+// JSONPointerSyntax is a RFC 6901 JSON Pointer,
+// every key part is prefixed with a slash,
+// tilde and slash in the key parts are escaped as ~0 and ~1.
+type JSONPointerSyntax struct{}
+
+var (
+	jsonPointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func (JSONPointerSyntax) JoinKey(prefix, part string) string {
+	return prefix + "/" + jsonPointerEscaper.Replace(part)
+}
+
+func (JSONPointerSyntax) SplitKey(key string) ([]string, error) {
+	parts := []string{}
+	if key == "" {
+		return parts, nil
+	}
+	if key[0] != '/' {
+		return nil, NewErrInvalidKey(key, 0)
+	}
+
+	offset := 1
+	for _, part := range strings.Split(key[1:], "/") {
+		for k := 0; k < len(part); k++ {
+			if part[k] != '~' {
+				continue
+			}
+			if k+1 == len(part) || (part[k+1] != '0' && part[k+1] != '1') {
+				return nil, NewErrInvalidKey(key, offset+k)
+			}
+		}
+		parts = append(parts, jsonPointerUnescaper.Replace(part))
+		offset += len(part) + 1
+	}
+
+	return parts, nil
+}
+
+//
+
+// BracketSyntax is a bracket notation like in JavaScript,
+// identifiers are joined with a dot, indexes are put into
+// brackets and other key parts are quoted in brackets(a["b.c"][0]).
+type BracketSyntax struct{}
+
+func (BracketSyntax) JoinKey(prefix, part string) string {
+	switch {
+	case isIdentifier(part):
+		if prefix == "" {
+			return part
+		}
+		return prefix + "." + part
+	case isIndex(part):
+		return prefix + "[" + part + "]"
+	default:
+		return prefix + "[" + strconv.Quote(part) + "]"
+	}
+}
+
+func (BracketSyntax) SplitKey(key string) ([]string, error) {
+	var (
+		parts = []string{}
+		end   int
+	)
+
+	if key != "" && key[0] != '[' {
+		end = identifierEnd(key, 0)
+		if end == 0 {
+			return nil, NewErrInvalidKey(key, 0)
+		}
+		parts = append(parts, key[:end])
+	}
+
+	for k := end; k < len(key); k = end {
+		switch key[k] {
+		case '.':
+			end = identifierEnd(key, k+1)
+			if end == k+1 {
+				return nil, NewErrInvalidKey(key, k+1)
+			}
+			parts = append(parts, key[k+1:end])
+		case '[':
+			if k+1 < len(key) && key[k+1] == '"' {
+				end = quotedEnd(key, k+1)
+				part, err := strconv.Unquote(key[k+1 : end])
+				if err != nil {
+					return nil, NewErrInvalidKey(key, k+1)
+				}
+				parts = append(parts, part)
+			} else {
+				end = k + 1
+				for end < len(key) && key[end] >= '0' && key[end] <= '9' {
+					end++
+				}
+				if end == k+1 {
+					return nil, NewErrInvalidKey(key, k+1)
+				}
+				parts = append(parts, key[k+1:end])
+			}
+			if end >= len(key) || key[end] != ']' {
+				return nil, NewErrInvalidKey(key, end)
+			}
+			end++
+		default:
+			return nil, NewErrInvalidKey(key, k)
+		}
+	}
+
+	return parts, nil
+}
+
+// identifierEnd returns the offset after the identifier
+// which starts at the offset in the key.
+func identifierEnd(key string, offset int) int {
+	for offset < len(key) {
+		r, size := utf8.DecodeRuneInString(key[offset:])
+		if !isIdentifierRune(r) {
+			break
+		}
+		offset += size
+	}
+	return offset
+}
+
+// quotedEnd returns the offset after the quoted string
+// which starts at the offset in the key,
+// length of the key is returned if string is not terminated.
+func quotedEnd(key string, offset int) int {
+	for k := offset + 1; k < len(key); k++ {
+		switch key[k] {
+		case '\\':
+			k++
+		case '"':
+			return k + 1
+		}
+	}
+	return len(key)
+}
+
+func isIdentifier(part string) bool {
+	if part == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(part)
+	if unicode.IsDigit(r) {
+		return false
+	}
+	return identifierEnd(part, 0) == len(part)
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isIndex(part string) bool {
+	if part == "" {
+		return false
+	}
+	for k := 0; k < len(part); k++ {
+		if part[k] < '0' || part[k] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySyntaxes(t *testing.T) {
+	samples := []struct {
+		syntax KeySyntax
+		parts  []string
+		key    string
+	}{
+		{DelimitedSyntax{"."}, []string{"a", "b"}, `a.b`},
+		{DelimitedSyntax{"."}, []string{"a.b", "c"}, `a\.b.c`},
+		{DelimitedSyntax{"."}, []string{`a\`, "b"}, `a\\.b`},
+		{DelimitedSyntax{"::"}, []string{"a::b", "c"}, `a\::b::c`},
+		{JSONPointer, []string{"a/b", "c"}, `/a~1b/c`},
+		{JSONPointer, []string{"~", ""}, `/~0/`},
+		{Brackets, []string{"a", "b.c", "0"}, `a["b.c"][0]`},
+		{Brackets, []string{"0", "a", "b_1"}, `[0].a.b_1`},
+		{Brackets, []string{"a b", `"`}, `["a b"]["\""]`},
+	}
+
+	for _, sample := range samples {
+		key := ""
+		for _, part := range sample.parts {
+			key = sample.syntax.JoinKey(key, part)
+		}
+		assert.Equal(t, sample.key, key, spew.Sdump(sample))
+
+		parts, err := sample.syntax.SplitKey(key)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.Equal(t, sample.parts, parts, spew.Sdump(sample))
+	}
+}
+
+func TestKeySyntaxesInvalidKey(t *testing.T) {
+	samples := []struct {
+		syntax KeySyntax
+		key    string
+		offset int
+	}{
+		{DelimitedSyntax{"."}, `a\b`, 1},
+		{DelimitedSyntax{"."}, `a\`, 1},
+		{JSONPointer, `a/b`, 0},
+		{JSONPointer, `/a/b~2`, 4},
+		{Brackets, `a..b`, 2},
+		{Brackets, `a["b]`, 2},
+		{Brackets, `a[0`, 3},
+		{Brackets, `a[]`, 2},
+	}
+
+	for _, sample := range samples {
+		_, err := sample.syntax.SplitKey(sample.key)
+		assert.Equal(
+			t,
+			&ErrInvalidKey{sample.key, sample.offset},
+			err,
+			spew.Sdump(sample),
+		)
+	}
+}
+
+func TestBuilderKeySyntaxRoundTrip(t *testing.T) {
+	type Nested struct {
+		Host   string            `key:"a.b"`
+		Labels map[string]string `key:"labels"`
+	}
+	sample := Nested{
+		Host:   "localhost",
+		Labels: map[string]string{"app.kubernetes.io/name": "web"},
+	}
+
+	for _, test := range []struct {
+		syntax KeySyntax
+		keys   []string
+	}{
+		{DelimitedSyntax{"."}, []string{`a\.b`, `labels.app\.kubernetes\.io/name`}},
+		{JSONPointer, []string{`/a.b`, `/labels/app.kubernetes.io~1name`}},
+		{Brackets, []string{`["a.b"]`, `labels["app.kubernetes.io/name"]`}},
+	} {
+		builder := NewBuilder("key", ".")
+		builder.ExpandMaps = true
+		builder.KeySyntax = test.syntax
+
+		keys, err := builder.Keys(&sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.Equal(t, test.keys, keys, spew.Sdump(sample))
+
+		mapping, err := builder.Map(&sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		result := Nested{}
+		err = builder.Unmarshal(mapping, &result)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.Equal(t, sample, result, spew.Sdump(mapping))
+
+		expanded, err := builder.Expand(mapping)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		assert.Equal(
+			t,
+			map[string]interface{}{
+				"a.b":    "localhost",
+				"labels": map[string]interface{}{"app.kubernetes.io/name": "web"},
+			},
+			expanded,
+			spew.Sdump(mapping),
+		)
+	}
+}
@@ -0,0 +1,79 @@
+package flatstructs
+
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	// TagOptionOmitEmpty drops leafs with zero values.
+	// Applied to the nested struct field it drops
+	// zero leafs of the nested struct.
+	TagOptionOmitEmpty = "omitempty"
+
+	// TagOptionInline flattens the nested struct fields
+	// into the parent without adding the field name to the key.
+	TagOptionInline = "inline"
+
+	// TagOptionLeaf stops descent into the nested struct,
+	// it is represented as a single leaf.
+	TagOptionLeaf = "leaf"
+)
+
+// fieldTag is a parsed struct field tag.
+// Tag format is the same as in encoding/json:
+// name is followed by the comma separated options,
+// name "-" skips the field.
+type fieldTag struct {
+	name      string
+	named     bool
+	skip      bool
+	omitEmpty bool
+	inline    bool
+	leaf      bool
+}
+
+// fieldTag parses the struct field tag, field name
+// is used if there is no name in the tag, it is converted
+// with Builder.NameMapper if there is one.
+// If field has no Builder.Tag then the first of the
+// Builder.FallbackTags field has is parsed instead.
+func (b *Builder) fieldTag(field reflect.StructField) fieldTag {
+	var (
+		tag = field.Tag.Get(b.Tag)
+	)
+	for k := 0; tag == "" && k < len(b.FallbackTags); k++ {
+		tag = field.Tag.Get(b.FallbackTags[k])
+	}
+
+	var (
+		parts = strings.Split(tag, ",")
+		t     = fieldTag{name: parts[0]}
+	)
+
+	if tag == "-" {
+		t.skip = true
+		return t
+	}
+
+	for _, option := range parts[1:] {
+		switch option {
+		case TagOptionOmitEmpty:
+			t.omitEmpty = true
+		case TagOptionInline:
+			t.inline = true
+		case TagOptionLeaf:
+			t.leaf = true
+		}
+	}
+
+	t.named = t.name != ""
+	if !t.named {
+		t.name = field.Name
+		if b.NameMapper != nil {
+			t.name = b.NameMapper(field.Name)
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,181 @@
+package flatstructs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderMapTagSkip(t *testing.T) {
+	type Flat struct {
+		Foo string `key:"-"`
+		Bar string `key:"-,"`
+		Baz string `key:",omitempty"`
+	}
+	sample := Flat{"foo", "bar", "baz"}
+
+	mapping, err := Map(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"-":   "bar",
+			"Baz": "baz",
+		},
+		mapping,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderPairsTagOmitEmpty(t *testing.T) {
+	type Flat struct {
+		Baz  int
+		Jazz string
+	}
+	type Nested struct {
+		Foo  string    `key:"foo,omitempty"`
+		Bar  string    `key:"bar,omitempty"`
+		Time time.Time `key:"time,omitempty"`
+		Daz  Flat      `key:"daz,omitempty"`
+		Raz  Flat      `key:"raz"`
+	}
+	sample := Nested{
+		Foo: "foo",
+		Daz: Flat{Jazz: "jazz"},
+	}
+
+	pairs, err := NewBuilder("key", ".").Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"foo", "foo"},
+			{"daz.Jazz", "jazz"},
+			{"raz.Baz", 0},
+			{"raz.Jazz", ""},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysTagInline(t *testing.T) {
+	type Flat struct {
+		Baz string `key:"baz"`
+	}
+	type Nested struct {
+		Foo string `key:"foo"`
+		Bar Flat   `key:",inline"`
+		Daz *Flat  `key:"daz"`
+	}
+	sample := Nested{"foo", Flat{"bar"}, &Flat{"daz"}}
+
+	keys, err := NewBuilder("key", ".").Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"foo", "baz", "daz.baz"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderPairsTagLeaf(t *testing.T) {
+	type Flat struct {
+		Baz string
+	}
+	type Nested struct {
+		Foo string
+		Bar Flat  `key:"bar,leaf"`
+		Daz *Flat `key:"daz,leaf"`
+	}
+	sample := Nested{"foo", Flat{"bar"}, &Flat{"daz"}}
+
+	pairs, err := NewBuilder("key", ".").Pairs(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]Pair{
+			{"Foo", "foo"},
+			{"bar", Flat{"bar"}},
+			{"daz", Flat{"daz"}},
+		},
+		pairs,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalTagInline(t *testing.T) {
+	type Flat struct {
+		Baz string `key:"baz"`
+	}
+	type Nested struct {
+		Foo string `key:"-"`
+		Bar *Flat  `key:",inline"`
+	}
+	sample := Nested{}
+
+	err := Unmarshal(
+		map[string]interface{}{
+			"Foo": "foo",
+			"baz": "baz",
+		},
+		&sample,
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		Nested{Bar: &Flat{"baz"}},
+		sample,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysFallbackTags(t *testing.T) {
+	type Flat struct {
+		Foo  string `flat:"foo" json:"json_foo"`
+		Bar  string `json:"bar,omitempty" yaml:"yaml_bar"`
+		Baz  string `yaml:"baz"`
+		Qux  string `json:"-" yaml:"qux"`
+		Quux string
+	}
+	sample := Flat{Foo: "foo", Baz: "baz"}
+
+	builder := NewBuilder("flat", ".")
+	builder.FallbackTags = []string{"json", "yaml"}
+
+	keys, err := builder.Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"foo", "baz", "Quux"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
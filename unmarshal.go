@@ -0,0 +1,461 @@
+package flatstructs
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Unmarshal assigns values from a flat map(which has the same
+// layout Map() produces) to the matching fields of a nested
+// structure v points to.
+// Nil pointers to nested structures are allocated only if
+// there is at least one key in the map which belongs to them.
+func (b *Builder) Unmarshal(m map[string]interface{}, v interface{}) error {
+	err := checkValue(v)
+	if err != nil {
+		return err
+	}
+
+	reflectValue, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	if isUnflattenerType(reflectValue.Type()) {
+		_, err = b.fromMapUnflattener(m, reflectValue, "")
+		return err
+	}
+
+	_, err = b.fromMap(m, reflectValue, "")
+	return err
+}
+
+// fromMap, see Unmarshal().
+// It reports whether at least one key from the map was assigned.
+func (b *Builder) fromMap(m map[string]interface{}, reflectValue reflect.Value, prefix string) (bool, error) {
+	var (
+		fieldValue reflect.Value
+		key        string
+		value      interface{}
+		found      bool
+		ok         bool
+	)
+
+	p, err := b.plan(reflectValue.Type())
+	if err != nil {
+		return false, err
+	}
+
+	for _, field := range p.fields {
+		key = b.appendKey(prefix, field.key, field.parts)
+
+		if field.dynamic {
+			ok, err = b.fromMapDynamic(m, reflectValue, field, key)
+			if err != nil {
+				return false, err
+			}
+			found = found || ok
+			continue
+		}
+
+		value, ok = m[key]
+		if !ok {
+			continue
+		}
+		fieldValue = field.field(reflectValue, true)
+		if !assignValue(fieldValue, value) {
+			return false, NewErrUnassignable(key, value, fieldValue.Type())
+		}
+		found = true
+	}
+
+	return found, nil
+}
+
+// fromMapDynamic assigns a dynamic plan leaf, see fromMap().
+// Intermediate pointers are allocated only if
+// at least one key from the map was assigned.
+func (b *Builder) fromMapDynamic(m map[string]interface{}, reflectValue reflect.Value, field *planField, key string) (bool, error) {
+	fieldValue := field.field(reflectValue, false)
+	if fieldValue.IsValid() {
+		return b.fromMapValue(m, fieldValue, key)
+	}
+
+	nested := reflect.New(field.typ).Elem()
+	ok, err := b.fromMapValue(m, nested, key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	field.field(reflectValue, true).Set(nested)
+
+	return true, nil
+}
+
+// fromMapValue assigns a value which layout is known only at runtime,
+// see fromMap().
+func (b *Builder) fromMapValue(m map[string]interface{}, reflectValue reflect.Value, key string) (bool, error) {
+	if isUnflattenerType(reflectValue.Type()) {
+		return b.fromMapUnflattener(m, reflectValue, key)
+	}
+
+	value, ok := m[key]
+	if ok {
+		if !assignValue(reflectValue, value) {
+			return false, NewErrUnassignable(key, value, reflectValue.Type())
+		}
+		return true, nil
+	}
+
+	if !b.hasKeyPrefix(m, key) {
+		return false, nil
+	}
+
+	switch reflectValue.Kind() {
+	case reflect.Interface:
+		// Value of the interface is not addressable,
+		// so only values behind the pointers could be assigned.
+		if reflectValue.IsNil() {
+			break
+		}
+		if elem := reflectValue.Elem(); elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			return b.fromMapValue(m, elem, key)
+		}
+	case reflect.Ptr:
+		if !reflectValue.IsNil() {
+			return b.fromMapValue(m, reflectValue.Elem(), key)
+		}
+		nested := reflect.New(reflectValue.Type().Elem())
+		ok, err := b.fromMapValue(m, nested.Elem(), key)
+		if err != nil || !ok {
+			return false, err
+		}
+		reflectValue.Set(nested)
+		return true, nil
+	case reflect.Struct:
+		return b.fromMap(m, reflectValue, key)
+	case reflect.Slice:
+		if b.ExpandSlices && !isBytesType(reflectValue.Type()) {
+			return b.fromMapSlice(m, reflectValue, key)
+		}
+	case reflect.Array:
+		if b.ExpandSlices && !isBytesType(reflectValue.Type()) {
+			return b.fromMapArray(m, reflectValue, key)
+		}
+	case reflect.Map:
+		if b.ExpandMaps && isExpandableMapType(reflectValue.Type()) {
+			return b.fromMapMap(m, reflectValue, key)
+		}
+	}
+
+	return false, nil
+}
+
+// fromMapSlice assigns a slice from the keys with
+// sequential indexes starting from zero, see fromMapValue().
+func (b *Builder) fromMapSlice(m map[string]interface{}, reflectValue reflect.Value, key string) (bool, error) {
+	var (
+		items = reflect.MakeSlice(reflectValue.Type(), 0, 0)
+		item  reflect.Value
+		ok    bool
+		err   error
+	)
+
+	for n := 0; ; n++ {
+		item = reflect.New(reflectValue.Type().Elem()).Elem()
+		ok, err = b.fromMapValue(m, item, b.joinKey(key, strconv.Itoa(n)))
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			break
+		}
+		items = reflect.Append(items, item)
+	}
+
+	if items.Len() == 0 {
+		return false, nil
+	}
+	reflectValue.Set(items)
+
+	return true, nil
+}
+
+// fromMapArray assigns array elements, see fromMapValue().
+func (b *Builder) fromMapArray(m map[string]interface{}, reflectValue reflect.Value, key string) (bool, error) {
+	var (
+		found bool
+		ok    bool
+		err   error
+	)
+
+	for n := 0; n < reflectValue.Len(); n++ {
+		ok, err = b.fromMapValue(m, reflectValue.Index(n), b.joinKey(key, strconv.Itoa(n)))
+		if err != nil {
+			return false, err
+		}
+		found = found || ok
+	}
+
+	return found, nil
+}
+
+// fromMapMap assigns map entries using the key parts
+// which follow the key as a map keys, see fromMapValue().
+// Map keys could not be separated when there is no Builder.KeySyntax
+// and Builder.KeyDelimiter is empty, so nothing is assigned in this case.
+func (b *Builder) fromMapMap(m map[string]interface{}, reflectValue reflect.Value, key string) (bool, error) {
+	if b.KeySyntax == nil && b.KeyDelimiter == "" {
+		return false, nil
+	}
+
+	var (
+		reflectType = reflectValue.Type()
+		items       = reflectValue
+		item        reflect.Value
+		mapKey      reflect.Value
+		found       bool
+		ok          bool
+		err         error
+	)
+
+	if items.IsNil() {
+		items = reflect.MakeMap(reflectType)
+	}
+
+	for _, part := range b.nextKeyParts(m, key) {
+		mapKey, ok, err = parseMapKey(reflectType.Key(), part)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+
+		item = reflect.New(reflectType.Elem()).Elem()
+		if current := items.MapIndex(mapKey); current.IsValid() {
+			item.Set(current)
+		}
+
+		ok, err = b.fromMapValue(m, item, b.joinKey(key, part))
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			items.SetMapIndex(mapKey, item)
+			found = true
+		}
+	}
+
+	if found && reflectValue.IsNil() {
+		reflectValue.Set(items)
+	}
+
+	return found, nil
+}
+
+// nextKeyParts returns sorted unique key parts which
+// immediately follow the prefix key in the keys of the map.
+func (b *Builder) nextKeyParts(m map[string]interface{}, prefix string) []string {
+	var (
+		parts    = []string{}
+		seen     = map[string]bool{}
+		prefixes = b.keyPrefixParts(prefix)
+	)
+
+	for k := range m {
+		part, ok := b.nextKeyPart(k, prefix, prefixes)
+		if ok && !seen[part] {
+			seen[part] = true
+			parts = append(parts, part)
+		}
+	}
+	sort.Strings(parts)
+
+	return parts
+}
+
+// hasKeyPrefix reports whether there is a key in the map
+// which is nested under the prefix key.
+func (b *Builder) hasKeyPrefix(m map[string]interface{}, prefix string) bool {
+	prefixes := b.keyPrefixParts(prefix)
+	for k := range m {
+		if _, ok := b.nextKeyPart(k, prefix, prefixes); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// keyPrefixParts returns the key parts of the prefix key
+// which are required by the nextKeyPart() when there is a Builder.KeySyntax.
+func (b *Builder) keyPrefixParts(prefix string) []string {
+	if b.KeySyntax == nil || prefix == "" {
+		return nil
+	}
+	parts, err := b.KeySyntax.SplitKey(prefix)
+	if err != nil {
+		return nil
+	}
+	return parts
+}
+
+// nextKeyPart returns the key part which immediately follows
+// the prefix key in the key and reports whether key is nested under the prefix.
+// Keys which could not be parsed with the Builder.KeySyntax are not nested.
+func (b *Builder) nextKeyPart(key string, prefix string, prefixes []string) (string, bool) {
+	if b.KeySyntax == nil {
+		prefix = prefix + b.KeyDelimiter
+		if !strings.HasPrefix(key, prefix) {
+			return "", false
+		}
+
+		part := key[len(prefix):]
+		if length := strings.Index(part, b.KeyDelimiter); length >= 0 {
+			part = part[:length]
+		}
+		return part, true
+	}
+
+	if len(key) <= len(prefix) || !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	parts, err := b.KeySyntax.SplitKey(key)
+	if err != nil || len(parts) <= len(prefixes) || !Path(parts).HasPrefix(prefixes) {
+		return "", false
+	}
+	return parts[len(prefixes)], true
+}
+
+// assignValue sets v into the dst, allocating pointers and
+// converting between compatible kinds(numbers, named types) if required.
+// Numbers which could not be represented by the dst kind
+// (fractions, negative unsigned numbers or overflows) are not converted.
+// It reports whether v was assigned.
+func assignValue(dst reflect.Value, v interface{}) bool {
+	if v == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return true
+	}
+
+	src := reflect.ValueOf(v)
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+	case dst.Kind() == reflect.Ptr:
+		if !dst.IsNil() {
+			return assignValue(dst.Elem(), v)
+		}
+		ptr := reflect.New(dst.Type().Elem())
+		if !assignValue(ptr.Elem(), v) {
+			return false
+		}
+		dst.Set(ptr)
+	case isConvertible(src.Type(), dst.Type()):
+		if !isRepresentable(src, dst.Type()) {
+			return false
+		}
+		dst.Set(src.Convert(dst.Type()))
+	default:
+		return false
+	}
+
+	return true
+}
+
+// isConvertible reports whether from could be converted to
+// without changing the meaning of the value, for example
+// int to string conversion is not allowed
+// because it produces a rune.
+func isConvertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	if from.Kind() == to.Kind() {
+		return true
+	}
+	return isNumberKind(from.Kind()) && isNumberKind(to.Kind())
+}
+
+// isRepresentable reports whether the number in src
+// could be converted to the type without losing its value,
+// values which are not numbers are always representable.
+func isRepresentable(src reflect.Value, to reflect.Type) bool {
+	zero := reflect.Zero(to)
+
+	switch to.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case isIntKind(src.Kind()):
+			return !zero.OverflowInt(src.Int())
+		case isUintKind(src.Kind()):
+			return src.Uint() <= math.MaxInt64 && !zero.OverflowInt(int64(src.Uint()))
+		case isFloatKind(src.Kind()):
+			f := src.Float()
+			return f == math.Trunc(f) &&
+				f >= math.MinInt64 && f < math.MaxInt64 &&
+				!zero.OverflowInt(int64(f))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch {
+		case isIntKind(src.Kind()):
+			return src.Int() >= 0 && !zero.OverflowUint(uint64(src.Int()))
+		case isUintKind(src.Kind()):
+			return !zero.OverflowUint(src.Uint())
+		case isFloatKind(src.Kind()):
+			f := src.Float()
+			return f == math.Trunc(f) &&
+				f >= 0 && f < math.MaxUint64 &&
+				!zero.OverflowUint(uint64(f))
+		}
+	case reflect.Float32, reflect.Float64:
+		if isFloatKind(src.Kind()) {
+			return !zero.OverflowFloat(src.Float())
+		}
+	}
+
+	return true
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return isIntKind(kind) || isUintKind(kind) || isFloatKind(kind)
+}
+
+//
+
+// Unmarshal assigns values from a flat map to the matching
+// fields of a nested structure v points to.
+// It uses Default Builder.
+func Unmarshal(m map[string]interface{}, v interface{}) error {
+	return Default.Unmarshal(m, v)
+}
@@ -0,0 +1,320 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderUnmarshalNotPtr(t *testing.T) {
+	type Flat struct {
+		Foo string
+	}
+	sample := Flat{}
+
+	err := Unmarshal(map[string]interface{}{"Foo": "foo"}, sample)
+	if err == nil {
+		t.Error("Value as argument should be reported as ErrPtrRequired")
+		return
+	}
+
+	if _, ok := err.(*ErrPtrRequired); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrPtrRequired, got '%T'",
+			err,
+		)
+	}
+}
+
+func TestBuilderUnmarshalFlat(t *testing.T) {
+	type Flat struct {
+		Foo string `key:"foo"`
+		Bar int
+		baz string
+	}
+	sample := Flat{}
+
+	err := Unmarshal(
+		map[string]interface{}{
+			"foo": "foo",
+			"Bar": 1,
+			"baz": "baz",
+		},
+		&sample,
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		Flat{Foo: "foo", Bar: 1},
+		sample,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalNestedPtr(t *testing.T) {
+	type Flat struct {
+		Baz string `key:"baz"`
+	}
+	type Nested struct {
+		Foo  string `key:"foo"`
+		Bar  *Flat
+		Jazz *Flat
+		Daz  Flat
+	}
+	sample := Nested{}
+
+	err := NewBuilder("key", ".").Unmarshal(
+		map[string]interface{}{
+			"foo":     "foo",
+			"Bar.baz": "baz",
+			"Daz.baz": "daz",
+		},
+		&sample,
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		Nested{
+			Foo: "foo",
+			Bar: &Flat{"baz"},
+			Daz: Flat{"daz"},
+		},
+		sample,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalRecursive(t *testing.T) {
+	type Node struct {
+		Value int
+		Next  *Node
+	}
+	sample := Node{}
+
+	err := NewBuilder("key", ".").Unmarshal(
+		map[string]interface{}{
+			"Value":           1,
+			"Next.Value":      2,
+			"Next.Next.Value": 3,
+		},
+		&sample,
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		Node{1, &Node{2, &Node{3, nil}}},
+		sample,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalConvert(t *testing.T) {
+	type Port int
+	type Flat struct {
+		Port  Port
+		Ratio float32
+		Name  *string
+	}
+	sample := Flat{}
+
+	err := Unmarshal(
+		map[string]interface{}{
+			"Port":  float64(1337),
+			"Ratio": 0.5,
+			"Name":  "name",
+		},
+		&sample,
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	name := "name"
+	assert.Equal(
+		t,
+		Flat{1337, 0.5, &name},
+		sample,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderUnmarshalUnassignable(t *testing.T) {
+	type Flat struct {
+		Foo string
+	}
+	sample := Flat{}
+
+	err := Unmarshal(map[string]interface{}{"Foo": 1}, &sample)
+	if err == nil {
+		t.Error("Int assigned to string should be reported as ErrUnassignable")
+		return
+	}
+
+	if _, ok := err.(*ErrUnassignable); !ok {
+		t.Errorf(
+			"Invalid error type, expected ErrUnassignable, got '%T'",
+			err,
+		)
+	}
+}
+
+func TestBuilderUnmarshalUnrepresentable(t *testing.T) {
+	type Flat struct {
+		A int
+		B int8
+		C uint
+	}
+	samples := []map[string]interface{}{
+		{"A": 1.5},
+		{"B": 300.0},
+		{"C": -1.0},
+		{"C": -1},
+	}
+
+	for _, sample := range samples {
+		err := Unmarshal(sample, &Flat{})
+		if err == nil {
+			t.Errorf(
+				"Number which could not be represented by the field should be reported as ErrUnassignable, sample: %s",
+				spew.Sdump(sample),
+			)
+			continue
+		}
+
+		if _, ok := err.(*ErrUnassignable); !ok {
+			t.Errorf(
+				"Invalid error type, expected ErrUnassignable, got '%T'",
+				err,
+			)
+		}
+	}
+}
+
+func TestBuilderUnmarshalMapRoundTrip(t *testing.T) {
+	type Flat struct {
+		Baz  int
+		Jazz string
+	}
+	type Nested struct {
+		Foo string
+		Bar *Flat
+		Daz Flat
+	}
+	sample := Nested{"foo", &Flat{1, "jazz"}, Flat{2, "daz"}}
+
+	mapping, err := Map(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result := Nested{}
+	err = Unmarshal(mapping, &result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(mapping),
+	)
+}
+
+func TestBuilderUnmarshalExpandSlices(t *testing.T) {
+	type Item struct {
+		Name string
+	}
+	type Nested struct {
+		Items []Item
+		Ptrs  []*Item
+		Tags  [2]string
+		Ints  *[]int
+	}
+	sample := Nested{
+		Items: []Item{{"foo"}, {"bar"}},
+		Ptrs:  []*Item{{"baz"}},
+		Tags:  [2]string{"a", "b"},
+		Ints:  &[]int{1, 2},
+	}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+
+	mapping, err := builder.Map(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result := Nested{}
+	err = builder.Unmarshal(mapping, &result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(mapping),
+	)
+}
+
+func TestBuilderUnmarshalIndirection(t *testing.T) {
+	type Config struct {
+		Host string
+	}
+	type Nested struct {
+		Config **Config
+		Value  interface{}
+		Scalar interface{}
+	}
+	var (
+		config = &Config{"localhost"}
+	)
+	sample := Nested{
+		Config: &config,
+		Value:  &Config{"127.0.0.1"},
+		Scalar: "foo",
+	}
+
+	builder := NewBuilder("key", ".")
+
+	mapping, err := builder.Map(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result := Nested{Value: &Config{}}
+	err = builder.Unmarshal(mapping, &result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		sample,
+		result,
+		spew.Sdump(mapping),
+	)
+}
@@ -0,0 +1,388 @@
+package flatstructs
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+var (
+	// errStopWalk could be returned by the walk() callback
+	// to stop the walk when result was found.
+	errStopWalk = errors.New("stop walk")
+)
+
+// node is a position in the nested structure
+// which is reported by the walk().
+type node struct {
+	// key is a flat key of the node.
+	key string
+
+	// field is a Go path of the node relative to the parent
+	// struct, slice or map, use fieldPath() to get the full path,
+	// like Bar.Items[0].Name.
+	// Full path is joined only on demand from the chain of parents
+	// kept by the state because it is required only to report errors.
+	field string
+
+	// depth is a number of key parts in the key.
+	depth int
+
+	// path is a sequence of key parts in the key,
+	// it is tracked only if the root node has non nil path
+	// because most of the walks need only joined keys.
+	path Path
+
+	// state is shared by the nodes of the single walk,
+	// it is created when the walk descends into the first nested value
+	// or dereferences the first pointer.
+	state *walkState
+
+	// root is a pointer to the root struct,
+	// it is put into visits when the state is created,
+	// so the walk does not allocate the state for the flat structs.
+	root visit
+
+	value reflect.Value
+}
+
+// walkState tracks the chain of parents of the nodes
+// which are walked, see push() and enter().
+type walkState struct {
+	// parents are Go paths of the nodes in the chain
+	// of parents relative to each other, see fieldPath().
+	parents []string
+
+	// visits are pointers, maps and slices which are visited
+	// in the chain of parents, they are used to detect cycles.
+	// Chains are short, so they are kept in a stack
+	// which is cheaper to search than to maintain a map.
+	visits []visit
+
+	// parentsBuffer and visitsBuffer back parents and visits
+	// for the short chains, so the walk allocates only the state itself.
+	parentsBuffer [8]string
+	visitsBuffer  [8]visit
+}
+
+// visit is a pointer with its type, type is required
+// because struct and its first field share the same address.
+// Slices also have a length because subslices share
+// the same address with the slice they are made from.
+type visit struct {
+	reflectType reflect.Type
+	pointer     uintptr
+	length      int
+}
+
+// newWalkState creates a state of the walk,
+// root is put into visits if it is set, see node.root.
+func newWalkState(root visit) *walkState {
+	s := &walkState{}
+	s.parents = s.parentsBuffer[:0]
+	s.visits = s.visitsBuffer[:0]
+	if root.reflectType != nil {
+		s.visits = append(s.visits, root)
+	}
+
+	return s
+}
+
+// rootNode returns a node of the struct which reflectValue points to.
+// It should be called only for the values which passed structValue().
+func rootNode(reflectValue reflect.Value) node {
+	for reflectValue.Elem().Kind() == reflect.Ptr {
+		reflectValue = reflectValue.Elem()
+	}
+
+	return node{
+		root:  visit{reflectValue.Type(), reflectValue.Pointer(), 0},
+		value: reflectValue.Elem(),
+	}
+}
+
+// walkRoot walks the root node, see rootNode() and walk().
+func (b *Builder) walkRoot(n node, fn func(node) error) error {
+	if f, ok := flattener(n.value); ok {
+		return b.walkFlattener(n, f, fn)
+	}
+
+	return b.walk(n, fn)
+}
+
+// walk calls fn for every reachable leaf of the struct n.value
+// in the order of the fields, keys and field paths
+// of the leafs are prefixed with n.key and n.field.
+// It is the single traversal every flat representation is built on,
+// so keys and values could not disagree.
+func (b *Builder) walk(n node, fn func(node) error) error {
+	p, err := b.plan(n.value.Type())
+	if err != nil {
+		return err
+	}
+
+	pushed := n.push()
+	defer n.pop(pushed)
+
+	var (
+		leaf = node{
+			state: n.state,
+			root:  n.root,
+		}
+	)
+
+	for _, field := range p.fields {
+		leaf.value = field.value(n.value)
+		if !leaf.value.IsValid() {
+			continue
+		}
+		if field.dynamic && isNilValue(leaf.value) {
+			continue
+		}
+		if field.omitEmpty && isZeroValue(leaf.value) {
+			continue
+		}
+
+		leaf.key = b.appendKey(n.key, field.key, field.parts)
+		leaf.field = field.path
+		leaf.depth = n.depth + field.depth
+		if n.path != nil {
+			leaf.path = n.path.join(field.parts...)
+		}
+		if b.MaxDepth > 0 && leaf.depth > b.MaxDepth {
+			return NewErrMaxDepth(leaf.fieldPath(), b.MaxDepth)
+		}
+
+		if field.dynamic {
+			err = b.walkValue(leaf, fn)
+		} else {
+			err = fn(leaf)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkValue walks the n.value which layout is known only at runtime,
+// see walk().
+// Interfaces are walked using the layout of the concrete type
+// of their value, values which have no leafs of their own
+// are reported as is.
+func (b *Builder) walkValue(n node, fn func(node) error) error {
+	if f, ok := flattener(n.value); ok {
+		return b.walkFlattener(n, f, fn)
+	}
+
+	switch n.value.Kind() {
+	case reflect.Interface:
+		if n.value.IsNil() {
+			return fn(n)
+		}
+		n.value = n.value.Elem()
+		ok, err := b.isNestedType(n.value.Type())
+		if err != nil {
+			return err
+		}
+		if ok {
+			return b.walkValue(n, fn)
+		}
+	case reflect.Ptr:
+		if n.value.IsNil() {
+			return nil
+		}
+		err := n.enter()
+		if err != nil {
+			return err
+		}
+		defer n.leave()
+
+		n.value = n.value.Elem()
+		return b.walkValue(n, fn)
+	case reflect.Struct:
+		p, err := b.plan(n.value.Type())
+		if err != nil {
+			return err
+		}
+		if len(p.fields) > 0 {
+			return b.walk(n, fn)
+		}
+	case reflect.Slice, reflect.Array:
+		if b.ExpandSlices && !isBytesType(n.value.Type()) {
+			if n.value.Kind() == reflect.Slice && n.value.Len() > 0 {
+				err := n.enter()
+				if err != nil {
+					return err
+				}
+				defer n.leave()
+			}
+
+			pushed := n.push()
+			defer n.pop(pushed)
+
+			for k := 0; k < n.value.Len(); k++ {
+				child, err := b.indexNode(n, k)
+				if err != nil {
+					return err
+				}
+				err = b.walkValue(child, fn)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if b.ExpandMaps && isExpandableMapType(n.value.Type()) {
+			err := n.enter()
+			if err != nil {
+				return err
+			}
+			defer n.leave()
+
+			entries, err := sortedMapEntries(n.value)
+			if err != nil {
+				return err
+			}
+			pushed := n.push()
+			defer n.pop(pushed)
+
+			for _, entry := range entries {
+				child, err := b.mapEntryNode(n, entry)
+				if err != nil {
+					return err
+				}
+				err = b.walkValue(child, fn)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	return fn(n)
+}
+
+// enter marks the pointer, map or non-empty slice n.value as visited
+// until leave() is called, pointer which is visited twice
+// in the chain of parents is reported as ErrCycle.
+func (n *node) enter() error {
+	if n.state == nil {
+		n.state = newWalkState(n.root)
+	}
+
+	v := visit{n.value.Type(), n.value.Pointer(), 0}
+	if n.value.Kind() == reflect.Slice {
+		v.length = n.value.Len()
+	}
+	for _, visited := range n.state.visits {
+		if visited == v {
+			return NewErrCycle(n.fieldPath())
+		}
+	}
+	n.state.visits = append(n.state.visits, v)
+
+	return nil
+}
+
+// leave removes the pointer from visits, see enter().
+// It should be called only if enter() succeeded.
+func (n *node) leave() {
+	n.state.visits = n.state.visits[:len(n.state.visits)-1]
+}
+
+// indexNode returns a node of the slice or array element.
+func (b *Builder) indexNode(n node, k int) (node, error) {
+	return b.childNode(
+		n,
+		strconv.Itoa(k),
+		"["+strconv.Itoa(k)+"]",
+		n.value.Index(k),
+	)
+}
+
+// mapEntryNode returns a node of the map entry.
+func (b *Builder) mapEntryNode(n node, entry mapEntry) (node, error) {
+	return b.childNode(
+		n,
+		entry.key,
+		"["+strconv.Quote(entry.key)+"]",
+		entry.value,
+	)
+}
+
+// childNode returns a node which is nested into n
+// with a single key part, it reports ErrMaxDepth
+// if node is nested deeper than Builder.MaxDepth.
+// It should be called only after n was pushed, see push().
+func (b *Builder) childNode(n node, key string, field string, value reflect.Value) (node, error) {
+	child := node{
+		key:   b.joinKey(n.key, key),
+		field: field,
+		depth: n.depth + 1,
+		state: n.state,
+		root:  n.root,
+		value: value,
+	}
+	if n.path != nil {
+		child.path = n.path.join(key)
+	}
+	if b.MaxDepth > 0 && child.depth > b.MaxDepth {
+		return child, NewErrMaxDepth(child.fieldPath(), b.MaxDepth)
+	}
+
+	return child, nil
+}
+
+// push adds the node to the chain of parents of the nodes
+// which are nested into it until pop() is called,
+// it should be called before the nested nodes are created.
+// Nodes without field path(root) are not added,
+// push reports whether node was added.
+func (n *node) push() bool {
+	if n.field == "" {
+		return false
+	}
+	if n.state == nil {
+		n.state = newWalkState(n.root)
+	}
+	n.state.parents = append(n.state.parents, n.field)
+
+	return true
+}
+
+// pop removes the node from the chain of parents, see push().
+func (n *node) pop(pushed bool) {
+	if pushed {
+		n.state.parents = n.state.parents[:len(n.state.parents)-1]
+	}
+}
+
+// fieldPath returns a full Go path of the node.
+// Chain of parents is changed by the walk, so the path
+// is valid only until the walk leaves the node.
+func (n node) fieldPath() string {
+	path := ""
+	if n.state != nil {
+		for _, parent := range n.state.parents {
+			path = joinFieldPath(path, parent)
+		}
+	}
+
+	return joinFieldPath(path, n.field)
+}
+
+// joinFieldPath joins the Go field path with the prefix path,
+// index and map key selectors are joined without a dot.
+func joinFieldPath(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	if field == "" || field[0] == '[' {
+		return prefix + field
+	}
+	return prefix + "." + field
+}
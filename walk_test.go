@@ -0,0 +1,192 @@
+package flatstructs
+
+import (
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderKeysCycle(t *testing.T) {
+	type Node struct {
+		Value int
+		Next  *Node
+	}
+	sample := &Node{1, &Node{2, nil}}
+	sample.Next.Next = sample
+
+	keys, err := NewBuilder("key", ".").Keys(sample)
+
+	assert.Equal(
+		t,
+		&ErrCycle{"Next.Next"},
+		err,
+	)
+
+	assert.Equal(
+		t,
+		([]string)(nil),
+		keys,
+	)
+}
+
+func TestBuilderKeysSharedPointer(t *testing.T) {
+	type Node struct {
+		Value int
+		Left  *Node
+		Right *Node
+	}
+	leaf := &Node{Value: 2}
+	sample := Node{1, leaf, leaf}
+
+	keys, err := NewBuilder("key", ".").Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"Value", "Left.Value", "Right.Value"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderFlattenAnyCycle(t *testing.T) {
+	sample := map[string]interface{}{}
+	sample["items"] = []interface{}{sample}
+
+	_, err := NewBuilder("key", ".").FlattenAny(sample)
+
+	assert.Equal(
+		t,
+		&ErrCycle{`["items"][0]`},
+		err,
+	)
+}
+
+func TestBuilderFlattenAnySliceCycle(t *testing.T) {
+	sample := []interface{}{nil}
+	sample[0] = sample
+
+	_, err := NewBuilder("key", ".").FlattenAny(sample)
+
+	assert.Equal(
+		t,
+		&ErrCycle{`[0]`},
+		err,
+	)
+}
+
+func TestBuilderKeysSliceCycle(t *testing.T) {
+	type Nested struct {
+		Items interface{}
+	}
+	items := []interface{}{1, nil}
+	items[1] = items
+	sample := Nested{items}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+
+	_, err := builder.Keys(&sample)
+
+	assert.Equal(
+		t,
+		&ErrCycle{`Items[1]`},
+		err,
+	)
+}
+
+func TestBuilderKeysSharedSlice(t *testing.T) {
+	type Nested struct {
+		Foo []int
+		Bar []int
+		Baz []int
+	}
+	items := []int{1, 2}
+	sample := Nested{items, items, items[:1]}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+
+	keys, err := builder.Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"Foo.0", "Foo.1", "Bar.0", "Bar.1", "Baz.0"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysMaxDepth(t *testing.T) {
+	type Node struct {
+		Value int
+		Next  *Node
+	}
+	sample := Node{1, &Node{2, &Node{3, nil}}}
+
+	builder := NewBuilder("key", ".")
+	builder.MaxDepth = 2
+
+	keys, err := builder.Keys(&sample)
+
+	assert.Equal(
+		t,
+		&ErrMaxDepth{"Next.Next.Value", 2},
+		err,
+		spew.Sdump(sample),
+	)
+
+	assert.Equal(
+		t,
+		([]string)(nil),
+		keys,
+		spew.Sdump(sample),
+	)
+
+	builder = NewBuilder("key", ".")
+	builder.MaxDepth = 3
+
+	keys, err = builder.Keys(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.Equal(
+		t,
+		[]string{"Value", "Next.Value", "Next.Next.Value"},
+		keys,
+		spew.Sdump(sample),
+	)
+}
+
+func TestBuilderKeysMaxDepthSlices(t *testing.T) {
+	type Item struct {
+		Name string
+	}
+	type Nested struct {
+		Items []Item
+	}
+	sample := Nested{[]Item{{"foo"}}}
+
+	builder := NewBuilder("key", ".")
+	builder.ExpandSlices = true
+	builder.MaxDepth = 2
+
+	_, err := builder.Keys(&sample)
+
+	assert.Equal(
+		t,
+		&ErrMaxDepth{"Items[0].Name", 2},
+		err,
+		spew.Sdump(sample),
+	)
+}